@@ -0,0 +1,177 @@
+// Package bordergraph models countries and their land borders as an undirected graph.
+package bordergraph
+
+import (
+	"sort"
+
+	"country-info-service/restcountries"
+)
+
+// Graph is an undirected graph of countries keyed by ISO3 code, with an edge for every land border.
+type Graph struct {
+	adjacent   map[string][]string
+	landlocked map[string]bool
+}
+
+// New builds a border graph from REST Countries records. Borders are treated as symmetric, and
+// borders referring to countries that are not in the dataset are ignored.
+func New(countries []restcountries.Country) *Graph {
+	g := &Graph{
+		adjacent:   make(map[string][]string, len(countries)),
+		landlocked: make(map[string]bool, len(countries)),
+	}
+	for _, c := range countries {
+		g.adjacent[c.CCA3] = nil
+		g.landlocked[c.CCA3] = c.Landlocked
+	}
+
+	seen := map[[2]string]bool{}
+	addEdge := func(a, b string) {
+		if seen[[2]string{a, b}] {
+			return
+		}
+		seen[[2]string{a, b}] = true
+		g.adjacent[a] = append(g.adjacent[a], b)
+	}
+	for _, c := range countries {
+		for _, border := range c.Borders {
+			if _, ok := g.adjacent[border]; !ok || border == c.CCA3 {
+				continue
+			}
+			addEdge(c.CCA3, border)
+			addEdge(border, c.CCA3)
+		}
+	}
+
+	return g
+}
+
+// Has reports whether the country is part of the graph.
+func (g *Graph) Has(code string) bool {
+	_, ok := g.adjacent[code]
+	return ok
+}
+
+// Neighbors returns the countries sharing a land border with the country.
+func (g *Graph) Neighbors(code string) []string {
+	return g.adjacent[code]
+}
+
+// codes returns all countries in the graph in sorted order.
+func (g *Graph) codes() []string {
+	codes := make([]string, 0, len(g.adjacent))
+	for code := range g.adjacent {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Levels returns the countries reachable from a country grouped by the number of border crossings
+// needed to reach them, up to maxDepth crossings. Levels[0] holds the direct neighbors.
+func (g *Graph) Levels(from string, maxDepth int) [][]string {
+	visited := map[string]bool{from: true}
+	frontier := []string{from}
+	var levels [][]string
+	for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+		var next []string
+		for _, code := range frontier {
+			for _, neighbor := range g.adjacent[code] {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		if len(next) > 0 {
+			levels = append(levels, next)
+		}
+		frontier = next
+	}
+	return levels
+}
+
+// ShortestPath returns a path with the fewest border crossings between two countries, including
+// both ends. It returns nil if either country is unknown or no land route exists.
+func (g *Graph) ShortestPath(from, to string) []string {
+	if !g.Has(from) || !g.Has(to) {
+		return nil
+	}
+	if from == to {
+		return []string{from}
+	}
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		code := queue[0]
+		queue = queue[1:]
+		for _, neighbor := range g.adjacent[code] {
+			if _, seen := previous[neighbor]; seen {
+				continue
+			}
+			previous[neighbor] = code
+			if neighbor == to {
+				// Walk back from the destination to rebuild the path
+				var path []string
+				for step := to; step != ""; step = previous[step] {
+					path = append([]string{step}, path...)
+				}
+				return path
+			}
+			queue = append(queue, neighbor)
+		}
+	}
+	return nil
+}
+
+// Components returns the connected landmasses of the graph: groups of countries linked by land borders.
+// Each group is sorted by code, and groups are ordered from largest to smallest.
+func (g *Graph) Components() [][]string {
+	visited := map[string]bool{}
+	var components [][]string
+	for _, code := range g.codes() {
+		if visited[code] {
+			continue
+		}
+		visited[code] = true
+		component := []string{code}
+		for i := 0; i < len(component); i++ {
+			for _, neighbor := range g.adjacent[component[i]] {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					component = append(component, neighbor)
+				}
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+	return components
+}
+
+// Islands returns the countries without any land border, in sorted order.
+func (g *Graph) Islands() []string {
+	var islands []string
+	for _, code := range g.codes() {
+		if len(g.adjacent[code]) == 0 {
+			islands = append(islands, code)
+		}
+	}
+	return islands
+}
+
+// Enclaves returns the landlocked countries entirely surrounded by a single other country, in sorted order.
+func (g *Graph) Enclaves() []string {
+	var enclaves []string
+	for _, code := range g.codes() {
+		if g.landlocked[code] && len(g.adjacent[code]) == 1 {
+			enclaves = append(enclaves, code)
+		}
+	}
+	return enclaves
+}
@@ -0,0 +1,70 @@
+package bordergraph
+
+import (
+	"reflect"
+	"testing"
+
+	"country-info-service/restcountries"
+)
+
+// testGraph builds a small graph: a Nordic/Baltic landmass, South Africa with the enclave Lesotho,
+// and the island Iceland. Borders are deliberately listed on one side only in places.
+func testGraph() *Graph {
+	country := func(code string, landlocked bool, borders ...string) restcountries.Country {
+		return restcountries.Country{CCA3: code, Landlocked: landlocked, Borders: borders}
+	}
+	return New([]restcountries.Country{
+		country("NOR", false, "SWE", "FIN", "RUS"),
+		country("SWE", false, "NOR", "FIN"),
+		country("FIN", false, "NOR", "SWE", "RUS"),
+		country("RUS", false, "NOR", "FIN", "EST"),
+		country("EST", false, "LVA"),
+		country("LVA", false, "XXX"),
+		country("ZAF", false, "LSO"),
+		country("LSO", true),
+		country("ISL", false),
+	})
+}
+
+func TestShortestPath(t *testing.T) {
+	g := testGraph()
+
+	tests := []struct {
+		from, to string
+		want     []string
+	}{
+		{"NOR", "LVA", []string{"NOR", "RUS", "EST", "LVA"}},
+		{"SWE", "SWE", []string{"SWE"}},
+		{"LSO", "ZAF", []string{"LSO", "ZAF"}},
+		{"NOR", "ISL", nil},
+		{"NOR", "XXX", nil},
+	}
+	for _, tt := range tests {
+		if got := g.ShortestPath(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ShortestPath(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLevels(t *testing.T) {
+	got := testGraph().Levels("SWE", 3)
+	want := [][]string{{"NOR", "FIN"}, {"RUS"}, {"EST"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Levels(SWE, 3) = %v, want %v", got, want)
+	}
+}
+
+func TestComponentsIslandsEnclaves(t *testing.T) {
+	g := testGraph()
+
+	wantComponents := [][]string{{"EST", "FIN", "LVA", "NOR", "RUS", "SWE"}, {"LSO", "ZAF"}, {"ISL"}}
+	if got := g.Components(); !reflect.DeepEqual(got, wantComponents) {
+		t.Errorf("Components() = %v, want %v", got, wantComponents)
+	}
+	if got := g.Islands(); !reflect.DeepEqual(got, []string{"ISL"}) {
+		t.Errorf("Islands() = %v, want [ISL]", got)
+	}
+	if got := g.Enclaves(); !reflect.DeepEqual(got, []string{"LSO"}) {
+		t.Errorf("Enclaves() = %v, want [LSO]", got)
+	}
+}
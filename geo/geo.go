@@ -0,0 +1,71 @@
+// Package geo provides great-circle distances and bounding boxes for latitude/longitude coordinates.
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadiusKm is the mean radius of the Earth in kilometers.
+const EarthRadiusKm = 6371.0088
+
+// Point is a position in decimal degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// PointFromLatLng converts a REST Countries [lat, lng] pair to a Point.
+func PointFromLatLng(latlng []float64) (Point, bool) {
+	if len(latlng) != 2 {
+		return Point{}, false
+	}
+	return Point{Lat: latlng[0], Lng: latlng[1]}, true
+}
+
+// Valid reports whether the point is within the valid latitude and longitude ranges.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance returns the great-circle distance between two points in kilometers, using the haversine formula.
+func Distance(a, b Point) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	lat1, lat2 := toRadians(a.Lat), toRadians(b.Lat)
+	dLat, dLng := lat2-lat1, toRadians(b.Lng-a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox is an area between two latitudes and two longitudes. If MinLng is greater than MaxLng
+// the box crosses the antimeridian (180° longitude).
+type BoundingBox struct {
+	MinLat float64 `json:"minLat"`
+	MinLng float64 `json:"minLng"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLng float64 `json:"maxLng"`
+}
+
+// Validate checks that the box has valid coordinates and that its latitudes are ordered.
+func (b BoundingBox) Validate() error {
+	if !(Point{Lat: b.MinLat, Lng: b.MinLng}).Valid() || !(Point{Lat: b.MaxLat, Lng: b.MaxLng}).Valid() {
+		return errors.New("coordinates out of range")
+	}
+	if b.MinLat > b.MaxLat {
+		return errors.New("minLat cannot be greater than maxLat")
+	}
+	return nil
+}
+
+// Contains reports whether the point lies within the box, edges included.
+func (b BoundingBox) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLng <= b.MaxLng {
+		return p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+	}
+	// The box crosses the antimeridian
+	return p.Lng >= b.MinLng || p.Lng <= b.MaxLng
+}
@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	oslo := Point{Lat: 59.92, Lng: 10.75}
+	madrid := Point{Lat: 40.4, Lng: -3.68}
+
+	// Oslo-Madrid is about 2,390 km along a great circle
+	if d := Distance(oslo, madrid); math.Abs(d-2390) > 20 {
+		t.Errorf("Distance(Oslo, Madrid) = %.0f km, want about 2390 km", d)
+	}
+	if d := Distance(oslo, oslo); d != 0 {
+		t.Errorf("Distance to itself = %v, want 0", d)
+	}
+
+	// Half the circumference between antipodes
+	if d := Distance(Point{0, 0}, Point{0, 180}); math.Abs(d-math.Pi*EarthRadiusKm) > 1e-6 {
+		t.Errorf("Distance between antipodes = %v, want %v", d, math.Pi*EarthRadiusKm)
+	}
+}
+
+func TestBoundingBoxContains(t *testing.T) {
+	nordics := BoundingBox{MinLat: 54, MinLng: 4, MaxLat: 72, MaxLng: 32}
+	if !nordics.Contains(Point{Lat: 62, Lng: 10}) {
+		t.Error("expected Norway inside the Nordic box")
+	}
+	if nordics.Contains(Point{Lat: 40.4, Lng: -3.68}) {
+		t.Error("expected Spain outside the Nordic box")
+	}
+
+	// A box across the antimeridian, around Fiji
+	pacific := BoundingBox{MinLat: -25, MinLng: 170, MaxLat: -10, MaxLng: -170}
+	if !pacific.Contains(Point{Lat: -18, Lng: 178}) || !pacific.Contains(Point{Lat: -18, Lng: -179}) {
+		t.Error("expected points on both sides of the antimeridian inside the box")
+	}
+	if pacific.Contains(Point{Lat: -18, Lng: 0}) {
+		t.Error("expected point at longitude 0 outside the box")
+	}
+}
+
+func TestBoundingBoxValidate(t *testing.T) {
+	if err := (BoundingBox{MinLat: 10, MaxLat: 0}).Validate(); err == nil {
+		t.Error("expected error for minLat greater than maxLat")
+	}
+	if err := (BoundingBox{MinLat: -91, MaxLat: 0}).Validate(); err == nil {
+		t.Error("expected error for latitude out of range")
+	}
+	if err := (BoundingBox{MinLat: -10, MinLng: 170, MaxLat: 10, MaxLng: -170}).Validate(); err != nil {
+		t.Errorf("unexpected error for antimeridian box: %v", err)
+	}
+}
+
+func TestPointGeometry(t *testing.T) {
+	// GeoJSON orders coordinates longitude first
+	if got := string((Point{Lat: 59.92, Lng: 10.75}).Geometry()); got != `{"type":"Point","coordinates":[10.75,59.92]}` {
+		t.Errorf("Geometry() = %s", got)
+	}
+	if got := string(NewFeature("NO", nil, nil).Geometry); got != "null" {
+		t.Errorf("missing geometry encoded as %s, want null", got)
+	}
+}
+
+func TestLoadBorders(t *testing.T) {
+	borders, err := LoadBorders("testdata/borders.geojson")
+	if err != nil {
+		t.Fatalf("LoadBorders: %v", err)
+	}
+
+	for _, code := range []string{"LS", "LSO", "lso"} {
+		if _, ok := borders.Geometry(code); !ok {
+			t.Errorf("expected a border for %s", code)
+		}
+	}
+	// Norway's ISO codes are "-99" in Natural Earth, so it is found through ADM0_A3 only
+	if _, ok := borders.Geometry("NO", "NOR"); !ok {
+		t.Error("expected a border for Norway through ADM0_A3")
+	}
+	if _, ok := borders.Geometry("-99"); ok {
+		t.Error("expected placeholder code -99 not to be indexed")
+	}
+
+	if _, err := LoadBorders("testdata/missing.geojson"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestDefaultBorders(t *testing.T) {
+	borders := DefaultBorders()
+	for _, codes := range [][]string{{"ISL", "IS"}, {"NOR", "NO"}, {"LSO", "LS"}, {"BRA", "BR"}} {
+		if _, ok := borders.Geometry(codes...); !ok {
+			t.Errorf("expected a bundled border for %v", codes)
+		}
+	}
+}
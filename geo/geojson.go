@@ -0,0 +1,131 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FeatureCollection is a GeoJSON (RFC 7946) FeatureCollection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature. Geometry holds an encoded GeoJSON geometry, or null if the location is unknown.
+type Feature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id,omitempty"`
+	Geometry   json.RawMessage `json:"geometry"`
+	Properties interface{}     `json:"properties"`
+}
+
+// NewFeatureCollection returns a FeatureCollection of the features.
+func NewFeatureCollection(features []Feature) *FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return &FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// NewFeature returns a Feature with the given geometry. A nil geometry is encoded as null.
+func NewFeature(id string, geometry json.RawMessage, properties interface{}) Feature {
+	if geometry == nil {
+		geometry = json.RawMessage("null")
+	}
+	return Feature{Type: "Feature", ID: id, Geometry: geometry, Properties: properties}
+}
+
+// Geometry returns the point as a GeoJSON Point geometry. GeoJSON orders coordinates longitude first.
+func (p Point) Geometry() json.RawMessage {
+	geometry, _ := json.Marshal(struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	}{"Point", [2]float64{p.Lng, p.Lat}})
+	return geometry
+}
+
+// defaultBorders are Natural Earth's 1:110m admin 0 countries (public domain), with the properties reduced to the
+// name and ISO codes and the coordinates rounded to two decimals.
+//
+//go:embed data/borders.geojson
+var defaultBorders []byte
+
+// Borders holds country border geometries, indexed by ISO code.
+type Borders struct {
+	byCode map[string]json.RawMessage
+}
+
+// borderCodeProperties are the feature properties that may hold a country's ISO code, in the order they are
+// tried. Natural Earth uses ISO_A3/ISO_A2, with ADM0_A3 for countries whose ISO_A3 is "-99" (e.g., France, Norway).
+var borderCodeProperties = []string{"ISO_A3", "ISO_A2", "ADM0_A3", "ISO_A3_EH", "iso_a3", "iso_a2", "ISO3166-1-Alpha-3", "ISO3166-1-Alpha-2"}
+
+// LoadBorders reads country borders from a GeoJSON FeatureCollection, such as Natural Earth's
+// admin 0 countries. Each feature is indexed by every ISO2 and ISO3 code among its properties.
+func LoadBorders(path string) (*Borders, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read borders file: %w", err)
+	}
+	return parseBorders(data)
+}
+
+// DefaultBorders returns the simplified country borders bundled with the service. They are coarse, but small
+// enough to embed; use LoadBorders for a more detailed dataset.
+func DefaultBorders() *Borders {
+	borders, err := parseBorders(defaultBorders)
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled borders: %v", err))
+	}
+	return borders
+}
+
+// parseBorders decodes a GeoJSON FeatureCollection and indexes its features by ISO code.
+func parseBorders(data []byte) (*Borders, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   json.RawMessage        `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse borders file: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("borders file is not a GeoJSON FeatureCollection")
+	}
+
+	borders := &Borders{byCode: map[string]json.RawMessage{}}
+	for _, feature := range collection.Features {
+		if len(feature.Geometry) == 0 || string(feature.Geometry) == "null" {
+			continue
+		}
+		for _, property := range borderCodeProperties {
+			code, _ := feature.Properties[property].(string)
+			code = strings.ToUpper(code)
+			if len(code) < 2 || len(code) > 3 || code == "-99" {
+				continue
+			}
+			if _, exists := borders.byCode[code]; !exists {
+				borders.byCode[code] = feature.Geometry
+			}
+		}
+	}
+	if len(borders.byCode) == 0 {
+		return nil, fmt.Errorf("borders file has no features with ISO codes")
+	}
+	return borders, nil
+}
+
+// Geometry returns the border geometry of the first of the codes found.
+func (b *Borders) Geometry(codes ...string) (json.RawMessage, bool) {
+	for _, code := range codes {
+		if geometry, ok := b.byCode[strings.ToUpper(code)]; ok {
+			return geometry, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"country-info-service/middleware"
+	"country-info-service/utils"
+)
+
+// Auth is the API key authenticator. The admin endpoints use it to report key usage.
+var Auth *middleware.Authenticator
+
+// ReloadConfig reloads the configuration files, returning the settings that could not be loaded. It is set by
+// main, which owns the configuration.
+var ReloadConfig func() []error
+
+// ReloadResponse reports a configuration reload.
+type ReloadResponse struct {
+	ReloadedAt time.Time `json:"reloadedAt"`
+	Errors     []string  `json:"errors,omitempty"` // Settings that failed to load and kept their previous values
+}
+
+// MaintenanceResponse reports whether upstream maintenance mode is on.
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UsageResponse lists the usage counters of every API key.
+type UsageResponse struct {
+	Count int                   `json:"count"`
+	Keys  []middleware.KeyUsage `json:"keys"`
+}
+
+// requireAdmin checks that the request was authenticated with an admin API key. Otherwise it responds with
+// 401 Unauthorized (no key) or 403 Forbidden (not an admin key) and returns false.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	key, ok := middleware.KeyFromContext(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="countryinfo"`)
+		middleware.WriteProblem(w, r, http.StatusUnauthorized, "An admin API key is required.")
+		return false
+	}
+	if !key.Admin {
+		middleware.WriteProblem(w, r, http.StatusForbidden, "The API key is not an admin key.")
+		return false
+	}
+	return true
+}
+
+// UsageHandler handles requests for the usage counters of every API key. Only admin keys may use it.
+//
+// Endpoint: GET /countryinfo/admin/usage
+//
+// Response:
+//   A JSON object with, for each API key (by name, the key itself is never shown), the accepted requests in
+//   total, today and per endpoint, the daily quota, the rejected requests and when it was last used.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 401 Unauthorized: Missing or invalid API key.
+//   - 403 Forbidden: The API key is not an admin key.
+func UsageHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	usage := []middleware.KeyUsage{}
+	if Auth != nil {
+		usage = Auth.Usage()
+	}
+
+	// Send response. The counters change with every request and are only for admins.
+	setNoStore(w)
+	writeListResponse(w, r, UsageResponse{Count: len(usage), Keys: usage}, "keys")
+}
+
+// CacheHandler handles requests to view or clear the upstream cache. Only admin keys may use it.
+//
+// Endpoint: GET|DELETE /countryinfo/admin/cache?filter={text}
+//
+// Parameters:
+//   - filter (optional): (string) Only list entries whose key contains the text (e.g., "alpha/NO"). Keys are
+//     upstream URLs, followed by the JSON payload for POST requests.
+//
+// Example Requests:
+//   - GET /countryinfo/admin/cache
+//   - GET /countryinfo/admin/cache?filter=population
+//   - DELETE /countryinfo/admin/cache
+//
+// Response:
+//   GET gives the cache TTL, whether maintenance mode is on, and the key, size in bytes, fetch time, age and
+//   freshness of every entry, with their total size. DELETE removes every entry and lists the removed keys.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 401 Unauthorized: Missing or invalid API key.
+//   - 403 Forbidden: The API key is not an admin key.
+//   - 405 Method Not Allowed: Method other than GET or DELETE.
+func CacheHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	setNoStore(w)
+	if r.Method == http.MethodDelete {
+		purged := utils.PurgeCache()
+		fmt.Println("Purged the upstream cache:", purged.Count, "entries")
+		writeListResponse(w, r, purged, "keys")
+		return
+	}
+	writeListResponse(w, r, utils.CacheContents(r.URL.Query().Get("filter")), "entries")
+}
+
+// CountryCacheHandler handles requests to purge or warm the upstream cache entries of a country: its REST
+// Countries record and its CountriesNow population, cities and states. Only admin keys may use it.
+//
+// Endpoint: DELETE|POST /countryinfo/admin/cache/{code}?refresh={true|false}
+//
+// Parameters:
+//   - code: (string) The ISO2 or ISO3 country code (e.g., "no" for Norway).
+//   - refresh (optional): (bool) With POST, purge the entries first so that everything is fetched again.
+//
+// Example Requests:
+//   - DELETE /countryinfo/admin/cache/no
+//   - POST /countryinfo/admin/cache/no
+//   - POST /countryinfo/admin/cache/no?refresh=true
+//
+// Response:
+//   DELETE lists the removed keys. POST fetches whatever is missing or stale, and gives the number and total
+//   size of the country's entries, with a warning for each resource that could not be fetched.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful (for POST, possibly with warnings).
+//   - 400 Bad Request: Invalid country code.
+//   - 401 Unauthorized: Missing or invalid API key.
+//   - 403 Forbidden: The API key is not an admin key.
+//   - 404 Not Found: Unknown country.
+//   - 405 Method Not Allowed: Method other than DELETE or POST.
+//   - 502 Bad Gateway: External API failure.
+//   - 503 Service Unavailable: The country record is not cached and maintenance mode is on.
+func CountryCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	setNoStore(w)
+
+	code := strings.ToUpper(r.PathValue("code"))
+	if matched, _ := regexp.MatchString("^[A-Z]{2,3}$", code); !matched {
+		middleware.WriteProblem(w, r, http.StatusBadRequest, "Invalid country code. Use an ISO2 or ISO3 code (e.g., 'NO').")
+		return
+	}
+
+	var (
+		response interface{}
+		list     string
+		err      error
+	)
+	if r.Method == http.MethodDelete {
+		response, err = utils.PurgeCountry(code)
+		list = "keys"
+	} else {
+		if r.URL.Query().Get("refresh") == "true" {
+			_, err = utils.PurgeCountry(code)
+		}
+		if err == nil {
+			response, err = utils.WarmCountry(code)
+		}
+		list = "warnings"
+	}
+	if err != nil {
+		fmt.Println("Error managing cache for country:", err)
+		if strings.Contains(err.Error(), "not found") {
+			middleware.WriteProblem(w, r, http.StatusNotFound, "Country not found in the database.")
+		} else if errors.Is(err, utils.ErrMaintenance) {
+			middleware.WriteProblem(w, r, http.StatusServiceUnavailable, "Maintenance mode is on, so the country cannot be fetched.")
+		} else {
+			middleware.WriteProblem(w, r, http.StatusBadGateway, "Failed to retrieve data from the external API.")
+		}
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, response, list)
+}
+
+// ReloadHandler handles requests to reload the configuration files without a restart: the exchange rates,
+// border polygons, rate limits and API keys are read again from the files named by EXCHANGE_RATES_FILE,
+// COUNTRY_BORDERS_FILE, RATE_LIMITS_FILE and API_KEYS_FILE. Settings given directly in environment variables
+// only take effect at startup. Only admin keys may use it.
+//
+// Endpoint: POST /countryinfo/admin/reload
+//
+// Response:
+//   A JSON object with the time of the reload and, if some settings failed to load, the errors. Those settings
+//   keep their previous values.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful (possibly with errors).
+//   - 401 Unauthorized: Missing or invalid API key.
+//   - 403 Forbidden: The API key is not an admin key.
+//   - 405 Method Not Allowed: Method other than POST.
+//   - 501 Not Implemented: Reloading is not configured.
+func ReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	setNoStore(w)
+	if ReloadConfig == nil {
+		middleware.WriteProblem(w, r, http.StatusNotImplemented, "Reloading the configuration is not supported.")
+		return
+	}
+
+	response := ReloadResponse{ReloadedAt: time.Now().UTC()}
+	for _, err := range ReloadConfig() {
+		fmt.Println("Error reloading configuration:", err)
+		response.Errors = append(response.Errors, err.Error())
+	}
+
+	// Send response
+	writeListResponse(w, r, response, "errors")
+}
+
+// MaintenanceHandler handles requests to view or toggle upstream maintenance mode. While it is on, the upstream
+// APIs are never called: cached responses are served however old they are, the country dataset falls back to
+// its snapshot, and requests needing anything else fail. Only admin keys may use it.
+//
+// Endpoint: GET|PUT /countryinfo/admin/maintenance
+//
+// Example Requests:
+//   - GET /countryinfo/admin/maintenance
+//   - PUT /countryinfo/admin/maintenance with the body {"enabled": true}
+//
+// Response:
+//   A JSON object telling whether maintenance mode is on (after the change, for PUT).
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid JSON body.
+//   - 401 Unauthorized: Missing or invalid API key.
+//   - 403 Forbidden: The API key is not an admin key.
+//   - 405 Method Not Allowed: Method other than GET or PUT.
+func MaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	setNoStore(w)
+
+	if r.Method == http.MethodPut {
+		var request struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+			middleware.WriteProblem(w, r, http.StatusBadRequest, `Invalid body. Send {"enabled": true} or {"enabled": false}.`)
+			return
+		}
+		utils.SetMaintenance(*request.Enabled)
+		fmt.Println("Upstream maintenance mode:", *request.Enabled)
+	}
+
+	// Send response
+	writeResponse(w, r, MaintenanceResponse{Enabled: utils.Maintenance()})
+}
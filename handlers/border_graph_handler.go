@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// RouteHandler handles requests for the shortest land route between two countries,
+// measured in border crossings. It is computed from the cached REST Countries dataset.
+//
+// Endpoint: GET /countryinfo/v1/route?from={code}&to={code}
+//
+// Parameters:
+//   - from: (string) ISO2 or ISO3 code of the starting country (e.g., "NO").
+//   - to: (string) ISO2 or ISO3 code of the destination country (e.g., "ES").
+//
+// Example Requests:
+//   - GET /countryinfo/v1/route?from=NO&to=ES
+//
+// Response:
+//   A JSON object with the number of border crossings and the countries along the path, including both ends.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Missing or invalid country code.
+//   - 404 Not Found: Unknown country, or no land route between the countries.
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func RouteHandler(w http.ResponseWriter, r *http.Request) {
+	from := strings.ToUpper(r.URL.Query().Get("from"))
+	to := strings.ToUpper(r.URL.Query().Get("to"))
+
+	// Check that both codes are ISO2 or ISO3
+	for _, code := range []string{from, to} {
+		if matched, _ := regexp.MatchString("^[A-Z]{2,3}$", code); !matched {
+			http.Error(w, "Missing or invalid 'from'/'to' parameter. Use ISO2 or ISO3 codes (e.g., 'from=NO&to=ES').", http.StatusBadRequest)
+			return
+		}
+	}
+
+	route, err := utils.FetchRoute(from, to)
+	if err != nil {
+		fmt.Println("Error finding route:", err)
+		writeBorderGraphError(w, err)
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, route, "path")
+}
+
+// LandmassesHandler handles requests for the groups of countries connected by land borders.
+//
+// Endpoint: GET /countryinfo/v1/landmasses?islands={true|false}
+//
+// Parameters:
+//   - islands (optional): (bool) Also include countries without land borders as landmasses of their own (default: false).
+//
+// Example Requests:
+//   - GET /countryinfo/v1/landmasses
+//   - GET /countryinfo/v1/landmasses?islands=true
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid query parameter.
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func LandmassesHandler(w http.ResponseWriter, r *http.Request) {
+	includeIslands := false
+	if queryIslands := r.URL.Query().Get("islands"); queryIslands != "" {
+		parsed, err := strconv.ParseBool(queryIslands)
+		if err != nil {
+			http.Error(w, "Invalid 'islands' parameter. Use 'true' or 'false'.", http.StatusBadRequest)
+			return
+		}
+		includeIslands = parsed
+	}
+
+	landmasses, err := utils.FetchLandmasses(includeIslands)
+	if err != nil {
+		fmt.Println("Error fetching landmasses:", err)
+		writeBorderGraphError(w, err)
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, landmasses, "landmasses")
+}
+
+// IslandsHandler handles requests for the countries without any land border.
+//
+// Endpoint: GET /countryinfo/v1/islands
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func IslandsHandler(w http.ResponseWriter, r *http.Request) {
+	islands, err := utils.FetchIslands()
+	if err != nil {
+		fmt.Println("Error fetching islands:", err)
+		writeBorderGraphError(w, err)
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, islands, "countries")
+}
+
+// EnclavesHandler handles requests for the landlocked countries entirely surrounded by a single other country.
+//
+// Endpoint: GET /countryinfo/v1/enclaves
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func EnclavesHandler(w http.ResponseWriter, r *http.Request) {
+	enclaves, err := utils.FetchEnclaves()
+	if err != nil {
+		fmt.Println("Error fetching enclaves:", err)
+		writeBorderGraphError(w, err)
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, enclaves, "countries")
+}
+
+// writeBorderGraphError maps errors from the border graph queries to HTTP responses.
+func writeBorderGraphError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		http.Error(w, "Country not found in the database.", http.StatusNotFound)
+	} else if strings.Contains(err.Error(), "no land route") {
+		http.Error(w, "No land route exists between the given countries.", http.StatusNotFound)
+	} else {
+		http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+	}
+}
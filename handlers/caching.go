@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"country-info-service/middleware"
+)
+
+// defaultCacheMaxAge is how long responses may be reused unless another lifetime is set.
+const defaultCacheMaxAge = 5 * time.Minute
+
+// cacheMaxAge holds the lifetime set with SetCacheMaxAge, if any.
+var cacheMaxAge atomic.Pointer[time.Duration]
+
+// SetCacheMaxAge sets how long clients and shared caches may reuse a response without revalidating it.
+// It may be called while requests are served.
+func SetCacheMaxAge(maxAge time.Duration) {
+	cacheMaxAge.Store(&maxAge)
+}
+
+// CacheMaxAge returns how long clients and shared caches may reuse a response without revalidating it.
+func CacheMaxAge() time.Duration {
+	if maxAge := cacheMaxAge.Load(); maxAge != nil {
+		return *maxAge
+	}
+	return defaultCacheMaxAge
+}
+
+// setLastModified sets the Last-Modified header to the time the response data was fetched from upstream.
+// writeBody uses it to answer If-Modified-Since requests.
+func setLastModified(w http.ResponseWriter, fetchedAt time.Time) {
+	if !fetchedAt.IsZero() {
+		w.Header().Set("Last-Modified", fetchedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// setNoCache makes clients revalidate a response before every reuse, e.g. for partial responses
+// that should be replaced as soon as the missing data is available.
+func setNoCache(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-cache")
+}
+
+// setNoStore keeps a response out of every cache, for admin responses that change with every request
+// and are only for the client that asked.
+func setNoStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "private, no-store")
+}
+
+// strongETag returns a strong entity tag for an encoded response body.
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeBody sends an encoded response with caching headers: a strong ETag computed from the body,
+// Cache-Control with CacheMaxAge unless the handler set its own, and the Last-Modified header set by the handler.
+// Responses to requests authenticated with an API key are private, so shared caches do not serve them to others.
+// Conditional GET and HEAD requests whose If-None-Match or If-Modified-Since is still current get
+// 304 Not Modified without a body.
+func writeBody(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	etag := strongETag(body)
+	w.Header().Set("ETag", etag)
+	if w.Header().Get("Cache-Control") == "" {
+		visibility := "public"
+		if _, authenticated := middleware.KeyFromContext(r.Context()); authenticated {
+			visibility = "private"
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, int(CacheMaxAge().Seconds())))
+	}
+
+	if notModified(r, etag, w.Header().Get("Last-Modified")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// notModified evaluates the conditional request headers. If-None-Match takes precedence over
+// If-Modified-Since, which is only used when the response has a Last-Modified time.
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			// If-None-Match uses the weak comparison, ignoring a W/ prefix
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	return err == nil && !modified.After(since)
+}
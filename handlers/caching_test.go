@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"country-info-service/middleware"
+)
+
+func TestWriteBodyConditional(t *testing.T) {
+	body := []byte(`{"mean":150}` + "\n")
+	fetchedAt := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	send := func(header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/countryinfo/v1/population/NO", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		setLastModified(w, fetchedAt)
+		writeBody(w, r, "application/json", body)
+		return w
+	}
+
+	first := send("", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || first.Body.String() != string(body) {
+		t.Fatalf("got %d %q, want 200 with the body", first.Code, first.Body.String())
+	}
+	if etag == "" || etag[0] != '"' {
+		t.Errorf("ETag = %q, want a strong entity tag", etag)
+	}
+	if got := first.Header().Get("Last-Modified"); got != "Tue, 02 Jan 2024 12:00:00 GMT" {
+		t.Errorf("Last-Modified = %q", got)
+	}
+	if got := first.Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	tests := []struct {
+		header, value string
+		want          int
+	}{
+		{"If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match", `"other", W/` + etag, http.StatusNotModified},
+		{"If-None-Match", `"other"`, http.StatusOK},
+		{"If-Modified-Since", "Tue, 02 Jan 2024 12:00:00 GMT", http.StatusNotModified},
+		{"If-Modified-Since", "Tue, 02 Jan 2024 11:59:59 GMT", http.StatusOK},
+		{"If-Modified-Since", "not a date", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := send(tt.header, tt.value)
+		if w.Code != tt.want {
+			t.Errorf("%s: %s gave %d, want %d", tt.header, tt.value, w.Code, tt.want)
+		}
+		if w.Code == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") != etag) {
+			t.Errorf("%s: 304 should have the ETag and no body", tt.header)
+		}
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since
+	r := httptest.NewRequest(http.MethodGet, "/countryinfo/v1/population/NO", nil)
+	r.Header.Set("If-None-Match", `"other"`)
+	r.Header.Set("If-Modified-Since", "Tue, 02 Jan 2024 12:00:00 GMT")
+	w := httptest.NewRecorder()
+	setLastModified(w, fetchedAt)
+	writeBody(w, r, "application/json", body)
+	if w.Code != http.StatusOK {
+		t.Errorf("got %d, want 200 when If-None-Match does not match", w.Code)
+	}
+}
+
+func TestWriteBodyAuthenticated(t *testing.T) {
+	auth, err := middleware.NewAuthenticator(middleware.AuthConfig{Keys: []middleware.APIKeyConfig{{Name: "etl", Key: "k"}}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeBody(w, r, "application/json", []byte(`{"mean":150}`+"\n"))
+	}))
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "public, max-age=300"},
+		{"k", "private, max-age=300"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/countryinfo/v1/population/NO", nil)
+		if tt.key != "" {
+			r.Header.Set("X-API-Key", tt.key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("key %q: Cache-Control = %q, want %q", tt.key, got, tt.want)
+		}
+		if got := w.Header().Get("Vary"); got != "Authorization, X-API-Key" {
+			t.Errorf("key %q: Vary = %q, want the key headers", tt.key, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// CitiesHandler handles requests for the cities of a country, optionally within one state or province.
+// Cities include population where CountriesNow provides it, and state with "states=true" or a state filter.
+//
+// Endpoint: GET /countryinfo/v1/cities/{code}?state={state}&limit={number}&sort={population|alpha}&order={asc|desc}&prefix={text}&contains={text}&offset={number}&cursor={cursor}&states={true|false}
+//
+// Parameters:
+//   - code: (string) The ISO2 country code (e.g., "no" for Norway).
+//   - state (optional): (string) Only list the cities of this state, by name or state code (e.g., "Rogaland").
+//   - limit (optional): (int) The maximum number of cities to return (default: 50).
+//   - sort, order, prefix, contains, offset, cursor, states (optional): As for /countryinfo/v1/info/{code}.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/cities/no
+//   - GET /countryinfo/v1/cities/us?state=California&sort=alpha&prefix=san
+//
+// Response:
+//   A JSON object with the country (and state) name, the number of matching cities, a cursor to the next page and the cities.
+//   If population or state data cannot be retrieved, the cities are returned without it, with "warnings" and the
+//   X-Partial-Response header describing what is missing.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid country code, or invalid query parameter.
+//   - 404 Not Found: Country or state not found.
+//   - 502 Bad Gateway: External API failure.
+func CitiesHandler(w http.ResponseWriter, r *http.Request) {
+	countryCode, ok := countryCodeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Extract the city query parameters, with "limit" defaulting to 50 if not provided
+	cityQuery, err := parseCityQuery(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch cities
+	cities, err := utils.FetchCountryCities(countryCode, r.URL.Query().Get("state"), cityQuery)
+	if err != nil {
+		fmt.Println("Error fetching cities:", err)
+		writeCitiesError(w, err)
+		return
+	}
+
+	// Send response
+	setPartialResponseHeader(w, cities.Warnings)
+	writeListResponse(w, r, cities, "cities")
+}
+
+// StatesHandler handles requests for the states or provinces of a country.
+//
+// Endpoint: GET /countryinfo/v1/states/{code}
+//
+// Parameters:
+//   - code: (string) The ISO2 country code (e.g., "no" for Norway).
+//
+// Example Requests:
+//   - GET /countryinfo/v1/states/no
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid country code.
+//   - 404 Not Found: Country not found.
+//   - 502 Bad Gateway: External API failure.
+func StatesHandler(w http.ResponseWriter, r *http.Request) {
+	countryCode, ok := countryCodeFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Fetch states
+	states, err := utils.FetchCountryStates(countryCode)
+	if err != nil {
+		fmt.Println("Error fetching states:", err)
+		writeCitiesError(w, err)
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, states, "states")
+}
+
+// countryCodeFromPath extracts and validates the ISO2 country code in the {code} path parameter.
+// It writes a 400 response and returns false if the code is invalid.
+func countryCodeFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	countryCode := strings.ToUpper(r.PathValue("code"))
+
+	// Check if the country code is in ISO2 format
+	if matched, _ := regexp.MatchString("^[A-Z]{2}$", countryCode); !matched {
+		http.Error(w, "Invalid country code. Use a valid ISO2 format (e.g., 'NO', 'US')", http.StatusBadRequest)
+		return "", false
+	}
+	return countryCode, true
+}
+
+// writeCitiesError maps errors from the city and state queries to HTTP responses.
+func writeCitiesError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "state not found") {
+		http.Error(w, "State not found for the given country.", http.StatusNotFound)
+	} else if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "status 404") {
+		http.Error(w, "Country not found in the database.", http.StatusNotFound)
+	} else {
+		http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+	}
+}
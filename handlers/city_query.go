@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// parseCityQuery reads the city sorting, filtering, pagination and enrichment query parameters
+// (limit, sort, order, prefix, contains, offset, cursor and states) shared by the endpoints returning cities.
+func parseCityQuery(r *http.Request, defaultLimit int) (utils.CityQuery, error) {
+	params := r.URL.Query()
+	query := utils.CityQuery{
+		Sort:     utils.CitySortPopulation,
+		Prefix:   params.Get("prefix"),
+		Contains: params.Get("contains"),
+		Limit:    defaultLimit,
+	}
+
+	// Extract the "limit" query parameter
+	if queryLimit := params.Get("limit"); queryLimit != "" {
+		parsedLimit, err := strconv.Atoi(queryLimit)
+		if err != nil || parsedLimit <= 0 {
+			return query, errors.New("invalid 'limit' parameter. Must be a positive integer.")
+		}
+		query.Limit = parsedLimit
+	}
+
+	// Validate sort key and order
+	switch sortParam := strings.ToLower(params.Get("sort")); sortParam {
+	case "":
+	case utils.CitySortPopulation, utils.CitySortAlpha:
+		query.Sort = sortParam
+	default:
+		return query, errors.New("invalid 'sort' parameter. Use 'population' or 'alpha'.")
+	}
+	switch order := strings.ToLower(params.Get("order")); order {
+	case "", "asc", "desc":
+		query.Order = order
+	default:
+		return query, errors.New("invalid 'order' parameter. Use 'asc' or 'desc'.")
+	}
+
+	// Position in the list is given either as an offset or as a cursor from a previous response
+	queryOffset, queryCursor := params.Get("offset"), params.Get("cursor")
+	if queryOffset != "" && queryCursor != "" {
+		return query, errors.New("use either 'offset' or 'cursor', not both.")
+	}
+	if queryOffset != "" {
+		offset, err := strconv.Atoi(queryOffset)
+		if err != nil || offset < 0 {
+			return query, errors.New("invalid 'offset' parameter. Must be a non-negative integer.")
+		}
+		query.Offset = offset
+	}
+	if queryCursor != "" {
+		offset, err := utils.DecodeCityCursor(queryCursor)
+		if err != nil {
+			return query, errors.New("invalid 'cursor' parameter. Use the next cursor of a previous response.")
+		}
+		query.Offset = offset
+	}
+
+	// Looking up the state of each city takes an upstream request per state, so it is opt-in
+	if queryStates := params.Get("states"); queryStates != "" {
+		states, err := strconv.ParseBool(queryStates)
+		if err != nil {
+			return query, errors.New("invalid 'states' parameter. Use 'true' or 'false'.")
+		}
+		query.States = states
+	}
+
+	return query, nil
+}
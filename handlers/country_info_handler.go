@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
-	"strconv"
 	"strings"
 	"fmt"
 	"regexp"
@@ -15,28 +13,54 @@ import (
 // CountryInfoHandler handles requests for country information based on an ISO2 country code.
 // It fetches country details and a list of major cities, with an optional limit on the number of cities.
 //
-// Endpoint: GET /countryinfo/v1/info/{code}?limit={number}
+// Endpoint: GET /countryinfo/v1/info/{code}?limit={number}&sort={population|alpha}&order={asc|desc}&prefix={text}&contains={text}&offset={number}&cursor={cursor}&states={true|false}&fields={field1,field2,...}&expand=borders&lang={language}&format={json|csv|xml|yaml|geojson}
 //
 // Parameters:
 //   - code: (string) The ISO2 country code (e.g., "no" for Norway).
 //   - limit (optional): (int) The maximum number of cities to include in the response (default: 10).
+//   - sort (optional): (string) Sort cities by "population" (default, cities without data last) or "alpha".
+//   - order (optional): (string) "asc" or "desc". Defaults to largest first for population and A-Z for alpha.
+//   - prefix, contains (optional): (string) Only include cities whose name starts with / contains the text (case-insensitive).
+//   - offset (optional): (int) The number of matching cities to skip (default: 0).
+//   - cursor (optional): (string) The citiesNextCursor of a previous response, instead of offset.
+//   - states (optional): (bool) "true" adds the state of each city, looking up at most 20 states (default: false).
+//   - fields (optional): (string) Comma-separated list of response fields to include (e.g., "name,capital,currencies").
+//     If not provided, all fields are returned.
+//   - expand (optional): (string) "borders" adds borderCountries, resolving each bordering country to its
+//     name, ISO2/ISO3 codes, capital, flag and population.
+//   - lang (optional): (string) Language for the country name (e.g., "de"), overriding the Accept-Language header.
+//   - format (optional): (string) "json" (default), "csv" (a single flattened row), "xml", "yaml" or the Accept header
+//     equivalent. "geojson" gives a GeoJSON FeatureCollection with a single feature, placed at the centroid or,
+//     with "point=capital", the capital, and the (selected) fields as its properties. With "borders=true" the
+//     feature is the country's border polygon, if available.
+//
+// Localization:
+//   The name and official name are returned in the first language from "lang" and then Accept-Language that
+//   REST Countries has a translation or native name for, and otherwise in English. Capital and language names are
+//   not translated by REST Countries and stay in English. The language used is sent in the Content-Language header.
 //
 // Example Requests:
 //   - GET /countryinfo/v1/info/no
 //   - GET /countryinfo/v1/info/us?limit=5
+//   - GET /countryinfo/v1/info/us?sort=alpha&prefix=san&limit=20&offset=20
+//   - GET /countryinfo/v1/info/se?fields=name,officialName,currencies,timezones
+//   - GET /countryinfo/v1/info/no?expand=borders
+//   - GET /countryinfo/v1/info/no?lang=fr
+//   - GET /countryinfo/v1/info/no?format=geojson&point=capital
+//
+// Partial Responses:
+//   If the cities or border countries cannot be retrieved, the rest of the country information is still returned.
+//   The missing field is null or omitted, the "warnings" field lists each failed resource and why, and the
+//   X-Partial-Response header lists the failed resources (e.g., "X-Partial-Response: cities").
 //
 // Possible HTTP Status Codes:
-//   - 200 OK: Request was successful.
-//   - 400 Bad Request: Missing or invalid country code, or invalid query parameter.
+//   - 200 OK: Request was successful (possibly a partial response, see above).
+//   - 400 Bad Request: Invalid country code, or invalid query parameter.
 //   - 500 Internal Server Error: Failed to fetch country information.
 func CountryInfoHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract country code from URL path
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 5 {
-		http.Error(w, "missing country code. Example: /countryinfo/v1/info/no", http.StatusBadRequest)
-		return
-	}
-	countryCode := strings.ToUpper(parts[4]) // Convert to uppercase (ISO2 codes are uppercase)
+	countryCode := strings.ToUpper(r.PathValue("code")) // Convert to uppercase (ISO2 codes are uppercase)
+
 	// For debugging
 	// fmt.Println("received country code:", countryCode)
 
@@ -46,21 +70,37 @@ func CountryInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract the "limit" query parameter, defaulting to 10 if not provided
-	limit := 10
-	if queryLimit := r.URL.Query().Get("limit"); queryLimit != "" {
-		parsedLimit, err := strconv.Atoi(queryLimit)
-		if err != nil || parsedLimit <= 0 {
-			http.Error(w, "invalid 'limit' parameter. Must be a positive integer.", http.StatusBadRequest)
+	// Extract the city query parameters, with "limit" defaulting to 10 if not provided
+	cityQuery, err := parseCityQuery(r, 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// for debugging
+	// fmt.Println("City query:", cityQuery)
+
+	// Extract the optional "fields" selector
+	var fields []string
+	if queryFields := r.URL.Query().Get("fields"); queryFields != "" {
+		fields, err = parseFields(queryFields, utils.CountryInfoResponse{})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid 'fields' parameter: %v.", err), http.StatusBadRequest)
 			return
 		}
-		limit = parsedLimit
 	}
-	// for debugging
-	// fmt.Println("Limit set to:", limit)
 
-	// Fetch country information using the provided country code and limit
-	info, err := utils.FetchCountryInfo(countryCode, limit)
+	// Extract the optional "expand" parameter
+	expandBorders := false
+	if queryExpand := r.URL.Query().Get("expand"); queryExpand != "" {
+		if strings.ToLower(queryExpand) != "borders" {
+			http.Error(w, "invalid 'expand' parameter. Supported value: 'borders'.", http.StatusBadRequest)
+			return
+		}
+		expandBorders = true
+	}
+
+	// Fetch country information using the provided country code and city query
+	info, err := utils.FetchCountryInfo(countryCode, cityQuery, preferredLanguages(r))
 	if err != nil {
 		fmt.Println("Error fetching country info:", err)
 
@@ -75,9 +115,37 @@ func CountryInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return the fetched country information as a JSON response
+	// Resolve bordering countries if requested. If that fails the rest of the response is still returned.
+	if expandBorders {
+		borderCountries, err := utils.FetchBorderCountries(info.Borders)
+		if err != nil {
+			fmt.Println("Error fetching border countries:", err)
+			info.Warnings = append(info.Warnings, utils.NewWarning("borderCountries", err))
+		}
+		info.BorderCountries = borderCountries
+	}
+
+	// Always report missing data, even when it was not among the selected fields
+	if len(fields) > 0 && len(info.Warnings) > 0 {
+		fields = append(fields, "warnings")
+	}
+
+	// Return the fetched country information in the requested format
 	// for debugging
 	// fmt.Println("Returning country info:", info)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(info)
+	selected, err := selectFields(info, fields)
+	if err != nil {
+		fmt.Println("Error encoding country info:", err)
+		http.Error(w, "internal server error while encoding data.", http.StatusInternalServerError)
+		return
+	}
+	setPartialResponseHeader(w, info.Warnings)
+	setLastModified(w, info.FetchedAt)
+	w.Header().Set("Content-Language", info.Language)
+	w.Header().Add("Vary", "Accept-Language")
+	if wantsGeoJSON(r) {
+		writeGeoJSON(w, r, []utils.FeatureSource{{Code: countryCode, Properties: selected}})
+		return
+	}
+	writeResponse(w, r, selected)
 }
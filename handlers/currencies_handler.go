@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// CurrenciesHandler handles requests for the currencies in use and the countries using each of them.
+//
+// Endpoints:
+//   - GET /countryinfo/v1/currencies
+//   - GET /countryinfo/v1/currencies/{currencyCode}
+//
+// Parameters:
+//   - currencyCode (optional): (string) An ISO 4217 currency code (e.g., "EUR") to describe a single currency.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/currencies
+//   - GET /countryinfo/v1/currencies/eur
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid currency code.
+//   - 404 Not Found: No country uses the currency.
+//   - 502 Bad Gateway: External API failure.
+func CurrenciesHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(r.PathValue("code"))
+
+	var (
+		result interface{}
+		list   = "currencies" // The list field, for CSV and NDJSON
+		err    error
+	)
+	if code == "" {
+		result, err = utils.FetchCurrencies()
+	} else {
+		// Check if the currency code is in ISO 4217 format
+		if matched, _ := regexp.MatchString("^[A-Z]{3}$", code); !matched {
+			http.Error(w, "Invalid currency code. Use a valid ISO 4217 format (e.g., 'EUR', 'NOK')", http.StatusBadRequest)
+			return
+		}
+		list = "countries"
+		result, err = utils.FetchCurrency(code)
+	}
+	if err != nil {
+		fmt.Println("Error fetching currencies:", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Currency not found.", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		}
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, result, list)
+}
+
+// ConvertHandler handles currency conversions using the configured exchange-rate provider
+// (by default stand-in rates from a bundled file). With perCapita set, the converted amount is also
+// divided by the population of a country, e.g. to turn a GDP into GDP per capita.
+//
+// Endpoint: GET /countryinfo/v1/convert?amount={number}&from={currency}&to={currency}&perCapita={countryCode}
+//
+// Parameters:
+//   - amount: (number) The amount to convert.
+//   - from: (string) ISO 4217 code of the currency of the amount (e.g., "USD").
+//   - to: (string) ISO 4217 code of the target currency. Optional if perCapita is given, defaulting to that country's currency.
+//   - perCapita (optional): (string) ISO2 or ISO3 code of the country whose population the result is divided by.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/convert?amount=100&from=EUR&to=NOK
+//   - GET /countryinfo/v1/convert?amount=482000000000&from=USD&perCapita=NO
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Missing or invalid parameter, or no rate for the currency.
+//   - 404 Not Found: Country not found.
+//   - 502 Bad Gateway: External API failure.
+func ConvertHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	amount, err := strconv.ParseFloat(params.Get("amount"), 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		http.Error(w, "Missing or invalid 'amount' parameter. Must be a number.", http.StatusBadRequest)
+		return
+	}
+
+	// Validate currency and country codes
+	from, to, country := strings.ToUpper(params.Get("from")), strings.ToUpper(params.Get("to")), params.Get("perCapita")
+	if matched, _ := regexp.MatchString("^[A-Z]{3}$", from); !matched {
+		http.Error(w, "Missing or invalid 'from' parameter. Use an ISO 4217 currency code (e.g., 'USD').", http.StatusBadRequest)
+		return
+	}
+	if matched, _ := regexp.MatchString("^([A-Z]{3})?$", to); !matched || (to == "" && country == "") {
+		http.Error(w, "Missing or invalid 'to' parameter. Use an ISO 4217 currency code (e.g., 'NOK'), or give 'perCapita'.", http.StatusBadRequest)
+		return
+	}
+	if matched, _ := regexp.MatchString("^([A-Za-z]{2,3})?$", country); !matched {
+		http.Error(w, "Invalid 'perCapita' parameter. Use an ISO2 or ISO3 country code (e.g., 'NO').", http.StatusBadRequest)
+		return
+	}
+
+	// Convert
+	conversion, err := utils.Convert(amount, from, to, country)
+	if err != nil {
+		fmt.Println("Error converting currency:", err)
+		if strings.Contains(err.Error(), "no exchange rate") || strings.Contains(err.Error(), "no currency") || strings.Contains(err.Error(), "no population") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Country not found in the database.", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		}
+		return
+	}
+	if math.IsInf(conversion.Result, 0) {
+		http.Error(w, "Invalid 'amount' parameter. The converted amount is too large.", http.StatusBadRequest)
+		return
+	}
+
+	// Send response
+	writeResponse(w, r, conversion)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonFieldName returns the JSON name of a struct field, or "" if the field is not encoded.
+func jsonFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" || !field.IsExported() {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// parseFields splits a comma-separated 'fields' query parameter and checks that every
+// requested name is a JSON field of the struct type of v.
+func parseFields(param string, v interface{}) ([]string, error) {
+	known := map[string]bool{}
+	t := reflect.Indirect(reflect.ValueOf(v)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonFieldName(t.Field(i)); name != "" {
+			known[name] = true
+		}
+	}
+
+	var fields []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown field: %s", name)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// selectFields encodes only the requested JSON fields of the struct v, keeping declaration order.
+// If no fields are requested the whole struct is encoded.
+func selectFields(v interface{}, fields []string) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return json.Marshal(v)
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range fields {
+		wanted[name] = true
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < value.NumField(); i++ {
+		name := jsonFieldName(value.Type().Field(i))
+		if name == "" || !wanted[name] {
+			continue
+		}
+		encoded, err := json.Marshal(value.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
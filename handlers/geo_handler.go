@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"country-info-service/geo"
+	"country-info-service/utils"
+)
+
+// maxRadiusKm is half the circumference of the Earth, the largest meaningful search radius.
+const maxRadiusKm = 20038
+
+// NearbyHandler handles requests for the countries within a radius of a point, nearest first.
+// Distances are great-circle distances computed from the cached REST Countries dataset.
+//
+// Endpoint: GET /countryinfo/v1/nearby?lat={latitude}&lng={longitude}&radius={km}&point={centroid|capital}&limit={number}&format={json|csv|xml|yaml|ndjson|geojson}
+//
+// Parameters:
+//   - lat, lng: (float) The point to search around, in decimal degrees.
+//   - radius (optional): (float) The search radius in kilometers, up to 20038 (default: 500).
+//   - point (optional): (string) Locate countries by their "centroid" (default) or their "capital".
+//   - limit (optional): (int) The maximum number of countries to return, between 1 and 250 (default: 20).
+//   - format (optional): (string) "json" (default), "csv" (a row per country), "xml", "yaml", "ndjson" (a line per
+//     country) or the Accept header equivalent. "geojson" gives a GeoJSON FeatureCollection with a feature per
+//     country, placed at the same point, and the country and its distance as properties. With "borders=true" the
+//     features are border polygons where available.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/nearby?lat=59.91&lng=10.75
+//   - GET /countryinfo/v1/nearby?lat=48.85&lng=2.35&radius=1000&point=capital&limit=5
+//   - GET /countryinfo/v1/nearby?lat=59.91&lng=10.75&radius=1500&format=geojson
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful (possibly with no countries).
+//   - 400 Bad Request: Missing or invalid query parameter.
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func NearbyHandler(w http.ResponseWriter, r *http.Request) {
+	lat, err := parseCoordinate(r, "lat", 90)
+	if err != nil {
+		http.Error(w, "Missing or invalid 'lat' parameter. Must be a number between -90 and 90.", http.StatusBadRequest)
+		return
+	}
+	lng, err := parseCoordinate(r, "lng", 180)
+	if err != nil {
+		http.Error(w, "Missing or invalid 'lng' parameter. Must be a number between -180 and 180.", http.StatusBadRequest)
+		return
+	}
+
+	// Extract the "radius" query parameter, defaulting to 500 km if not provided
+	radius := 500.0
+	if queryRadius := r.URL.Query().Get("radius"); queryRadius != "" {
+		parsedRadius, err := strconv.ParseFloat(queryRadius, 64)
+		if err != nil || math.IsNaN(parsedRadius) || parsedRadius <= 0 || parsedRadius > maxRadiusKm {
+			http.Error(w, fmt.Sprintf("Invalid 'radius' parameter. Must be a number of kilometers between 0 and %d.", maxRadiusKm), http.StatusBadRequest)
+			return
+		}
+		radius = parsedRadius
+	}
+
+	point, err := parseGeoPoint(r)
+	if err != nil {
+		http.Error(w, "Invalid 'point' parameter. Use 'centroid' or 'capital'.", http.StatusBadRequest)
+		return
+	}
+
+	// Extract the "limit" query parameter, defaulting to 20 if not provided
+	limit := 20
+	if queryLimit := r.URL.Query().Get("limit"); queryLimit != "" {
+		parsedLimit, err := strconv.Atoi(queryLimit)
+		if err != nil || parsedLimit <= 0 || parsedLimit > 250 {
+			http.Error(w, "Invalid 'limit' parameter. Must be an integer between 1 and 250.", http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	nearby, err := utils.FetchNearby(geo.Point{Lat: lat, Lng: lng}, radius, point, limit)
+	if err != nil {
+		fmt.Println("Error fetching nearby countries:", err)
+		http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		return
+	}
+
+	// Send response
+	if wantsGeoJSON(r) {
+		sources := make([]utils.FeatureSource, 0, len(nearby.Results))
+		for _, country := range nearby.Results {
+			sources = append(sources, utils.FeatureSource{Code: country.ISO3, Properties: country})
+		}
+		writeGeoJSON(w, r, sources)
+		return
+	}
+	writeListResponse(w, r, nearby, "results")
+}
+
+// BoundingBoxHandler handles requests for the countries located inside a bounding box.
+//
+// Endpoint: GET /countryinfo/v1/bbox?minLat={latitude}&minLng={longitude}&maxLat={latitude}&maxLng={longitude}&point={centroid|capital}&format={json|csv|xml|yaml|ndjson|geojson}
+//
+// Parameters:
+//   - minLat, maxLat: (float) The southern and northern edges of the box, in decimal degrees.
+//   - minLng, maxLng: (float) The western and eastern edges of the box, in decimal degrees. If minLng is greater
+//     than maxLng the box crosses the antimeridian (e.g., minLng=170&maxLng=-170).
+//   - point (optional): (string) Locate countries by their "centroid" (default) or their "capital".
+//   - format (optional): (string) "json" (default), "csv" (a row per country), "xml", "yaml", "ndjson" (a line per
+//     country) or the Accept header equivalent. "geojson" gives a GeoJSON FeatureCollection with a feature per
+//     country. With "borders=true" the features are border polygons where available.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/bbox?minLat=54&minLng=4&maxLat=72&maxLng=32
+//   - GET /countryinfo/v1/bbox?minLat=-25&minLng=170&maxLat=-10&maxLng=-170&point=capital
+//   - GET /countryinfo/v1/bbox?minLat=54&minLng=4&maxLat=72&maxLng=32&format=geojson&borders=true
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful (possibly with no countries).
+//   - 400 Bad Request: Missing or invalid query parameter.
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func BoundingBoxHandler(w http.ResponseWriter, r *http.Request) {
+	var box geo.BoundingBox
+	for _, param := range []struct {
+		name  string
+		limit float64
+		value *float64
+	}{
+		{"minLat", 90, &box.MinLat},
+		{"minLng", 180, &box.MinLng},
+		{"maxLat", 90, &box.MaxLat},
+		{"maxLng", 180, &box.MaxLng},
+	} {
+		value, err := parseCoordinate(r, param.name, param.limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Missing or invalid '%s' parameter. Must be a number between %g and %g.", param.name, -param.limit, param.limit), http.StatusBadRequest)
+			return
+		}
+		*param.value = value
+	}
+	if err := box.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid bounding box: %v.", err), http.StatusBadRequest)
+		return
+	}
+
+	point, err := parseGeoPoint(r)
+	if err != nil {
+		http.Error(w, "Invalid 'point' parameter. Use 'centroid' or 'capital'.", http.StatusBadRequest)
+		return
+	}
+
+	countries, err := utils.FetchBoundingBox(box, point)
+	if err != nil {
+		fmt.Println("Error fetching countries in bounding box:", err)
+		http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		return
+	}
+
+	// Send response
+	if wantsGeoJSON(r) {
+		sources := make([]utils.FeatureSource, 0, len(countries.Countries))
+		for _, country := range countries.Countries {
+			sources = append(sources, utils.FeatureSource{Code: country.ISO3, Properties: country})
+		}
+		writeGeoJSON(w, r, sources)
+		return
+	}
+	writeListResponse(w, r, countries, "countries")
+}
+
+// DistanceHandler handles requests for the great-circle distance between the capitals of two countries.
+//
+// Endpoint: GET /countryinfo/v1/distance?from={code}&to={code}
+//
+// Parameters:
+//   - from, to: (string) ISO2 or ISO3 codes of the two countries (e.g., "from=NO&to=ES").
+//
+// Example Requests:
+//   - GET /countryinfo/v1/distance?from=NO&to=ES
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Missing or invalid country code.
+//   - 404 Not Found: Unknown country, or a country without a capital (e.g., Antarctica).
+//   - 502 Bad Gateway: External API failure and no saved dataset available.
+func DistanceHandler(w http.ResponseWriter, r *http.Request) {
+	from := strings.ToUpper(r.URL.Query().Get("from"))
+	to := strings.ToUpper(r.URL.Query().Get("to"))
+
+	// Check that both codes are ISO2 or ISO3
+	for _, code := range []string{from, to} {
+		if matched, _ := regexp.MatchString("^[A-Z]{2,3}$", code); !matched {
+			http.Error(w, "Missing or invalid 'from'/'to' parameter. Use ISO2 or ISO3 codes (e.g., 'from=NO&to=ES').", http.StatusBadRequest)
+			return
+		}
+	}
+
+	distance, err := utils.FetchCapitalDistance(from, to)
+	if err != nil {
+		fmt.Println("Error computing distance:", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Country not found in the database.", http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "no capital") {
+			http.Error(w, "The country has no capital with known coordinates.", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		}
+		return
+	}
+
+	// Send response
+	writeResponse(w, r, distance)
+}
+
+// parseCoordinate reads a required latitude or longitude query parameter between -limit and limit degrees.
+func parseCoordinate(r *http.Request, name string, limit float64) (float64, error) {
+	query := r.URL.Query().Get(name)
+	if query == "" {
+		return 0, fmt.Errorf("missing %s", name)
+	}
+	value, err := strconv.ParseFloat(query, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value < -limit || value > limit {
+		return 0, fmt.Errorf("%s %q is not a number between %g and %g", name, query, -limit, limit)
+	}
+	return value, nil
+}
+
+// parseGeoPoint reads the optional "point" query parameter, choosing how countries are located.
+func parseGeoPoint(r *http.Request) (string, error) {
+	switch point := strings.ToLower(r.URL.Query().Get("point")); point {
+	case "", utils.GeoPointCentroid:
+		return utils.GeoPointCentroid, nil
+	case utils.GeoPointCapital:
+		return point, nil
+	default:
+		return "", fmt.Errorf("unknown point %q", point)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// wantsGeoJSON reports whether the client asks for GeoJSON, with "format=geojson" or an Accept header
+// of application/geo+json. Other formats are handled by writeResponse.
+func wantsGeoJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.EqualFold(format, "geojson")
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/geo+json")
+}
+
+// writeGeoJSON sends the countries as a GeoJSON FeatureCollection. The "point" query parameter chooses whether
+// features are placed at the centroid (default) or the capital, and "borders=true" uses border polygons instead
+// where the borders dataset has them. GeoJSON may be chosen by the Accept header, so the response varies by it.
+func writeGeoJSON(w http.ResponseWriter, r *http.Request, sources []utils.FeatureSource) {
+	w.Header().Add("Vary", "Accept")
+
+	point, err := parseGeoPoint(r)
+	if err != nil {
+		http.Error(w, "Invalid 'point' parameter. Use 'centroid' or 'capital'.", http.StatusBadRequest)
+		return
+	}
+	withBorders := false
+	if queryBorders := r.URL.Query().Get("borders"); queryBorders != "" {
+		withBorders, err = strconv.ParseBool(queryBorders)
+		if err != nil {
+			http.Error(w, "Invalid 'borders' parameter. Use 'true' or 'false'.", http.StatusBadRequest)
+			return
+		}
+	}
+
+	collection, err := utils.FetchFeatureCollection(sources, point, withBorders)
+	if err != nil {
+		fmt.Println("Error building GeoJSON:", err)
+		http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		return
+	}
+
+	// Send response
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(collection); err != nil {
+		fmt.Println("Error encoding GeoJSON:", err)
+		http.Error(w, "Internal server error while encoding data.", http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, r, "application/geo+json", body.Bytes())
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"country-info-service/utils"
+)
+
+func TestGeoJSONVariesByAccept(t *testing.T) {
+	previousTransport, previousSnapshot := http.DefaultTransport, utils.CountriesSnapshotFile()
+	defer func() {
+		http.DefaultTransport = previousTransport
+		utils.SetCountriesSnapshotFile(previousSnapshot)
+		utils.PurgeCache()
+	}()
+	http.DefaultTransport = fakeUpstream(t)
+	utils.SetCountriesSnapshotFile("")
+	utils.PurgeCache()
+	router := NewRouter()
+
+	tests := []struct {
+		path, accept string
+	}{
+		{"/countryinfo/v1/info/is?format=geojson", ""},
+		{"/countryinfo/v1/nearby?lat=64&lng=-20", "application/geo+json"},
+		{"/countryinfo/v1/rankings?format=geojson", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/geo+json" {
+			t.Errorf("%s: got %d %q, want 200 with GeoJSON", tt.path, w.Code, w.Header().Get("Content-Type"))
+		}
+		if !slices.Contains(w.Header().Values("Vary"), "Accept") {
+			t.Errorf("%s: Vary = %q, want Accept among them", tt.path, w.Header().Values("Vary"))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// preferredLanguages returns the language tags the client prefers, most preferred first.
+// A "lang" query parameter comes before the languages of the Accept-Language header, which are
+// ordered by their quality values. Wildcards and languages with q=0 are left out.
+func preferredLanguages(r *http.Request) []string {
+	var languages []string
+	if lang := strings.TrimSpace(r.URL.Query().Get("lang")); lang != "" {
+		languages = append(languages, lang)
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			accepted = append(accepted, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+
+	for _, a := range accepted {
+		languages = append(languages, a.tag)
+	}
+	return languages
+}
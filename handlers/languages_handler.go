@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// LanguagesHandler handles requests for the languages spoken in the world's countries, with the countries
+// speaking each language and their combined population.
+//
+// Endpoints:
+//   - GET /countryinfo/v1/languages
+//   - GET /countryinfo/v1/languages/{languageCode}
+//
+// Parameters:
+//   - languageCode (optional): (string) An ISO 639-3 language code as used by REST Countries (e.g., "nob"),
+//     or an ISO 639-1 code (e.g., "de"), to describe a single language.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/languages
+//   - GET /countryinfo/v1/languages/spa
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid language code.
+//   - 404 Not Found: No country speaks the language.
+//   - 502 Bad Gateway: External API failure.
+func LanguagesHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToLower(r.PathValue("code"))
+
+	var (
+		result interface{}
+		list   = "languages" // The list field, for CSV and NDJSON
+		err    error
+	)
+	if code == "" {
+		result, err = utils.FetchLanguages()
+	} else {
+		// Check if the language code is in ISO 639-1 or 639-3 format
+		if matched, _ := regexp.MatchString("^[a-z]{2,3}$", code); !matched {
+			http.Error(w, "Invalid language code. Use an ISO 639-3 or 639-1 code (e.g., 'nob', 'de')", http.StatusBadRequest)
+			return
+		}
+		list = "countries"
+		result, err = utils.FetchLanguage(code)
+	}
+	if err != nil {
+		fmt.Println("Error fetching languages:", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Language not found.", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		}
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, result, list)
+}
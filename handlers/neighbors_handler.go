@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// maxNeighborDepth limits how many border crossings a neighbors request may follow.
+const maxNeighborDepth = 5
+
+// NeighborsHandler handles requests for the countries reachable over land borders from a country.
+// With depth 1 it returns the direct neighbors, with depth 2 also the neighbors of neighbors, and so on.
+//
+// Endpoint: GET /countryinfo/v1/info/{code}/neighbors?depth={number}
+//
+// Parameters:
+//   - code: (string) The ISO2 country code (e.g., "no" for Norway).
+//   - depth (optional): (int) The number of border crossings to follow, between 1 and 5 (default: 1).
+//
+// Example Requests:
+//   - GET /countryinfo/v1/info/no/neighbors
+//   - GET /countryinfo/v1/info/de/neighbors?depth=2
+//
+// Response:
+//   A JSON object with the country code, the depth used and the neighbors found, ordered by depth.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid country code, or invalid query parameter.
+//   - 404 Not Found: Country not found.
+//   - 502 Bad Gateway: External API failure.
+func NeighborsHandler(w http.ResponseWriter, r *http.Request) {
+	// Extract country code from URL path
+	countryCode := strings.ToUpper(r.PathValue("code"))
+
+	// Check if country code is ISO2 format
+	if matched, _ := regexp.MatchString("^[A-Z]{2}$", countryCode); !matched {
+		http.Error(w, "invalid country code. Use a valid ISO2 format (e.g., 'NO', 'US')", http.StatusBadRequest)
+		return
+	}
+
+	// Extract the "depth" query parameter, defaulting to 1
+	depth := 1
+	if queryDepth := r.URL.Query().Get("depth"); queryDepth != "" {
+		parsedDepth, err := strconv.Atoi(queryDepth)
+		if err != nil || parsedDepth < 1 || parsedDepth > maxNeighborDepth {
+			http.Error(w, fmt.Sprintf("invalid 'depth' parameter. Must be an integer between 1 and %d.", maxNeighborDepth), http.StatusBadRequest)
+			return
+		}
+		depth = parsedDepth
+	}
+
+	// Fetch neighbors
+	neighbors, err := utils.FetchNeighbors(countryCode, depth)
+	if err != nil {
+		fmt.Println("Error fetching neighbors:", err)
+
+		// Differentiate error types
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "country not found in the database.", http.StatusNotFound)
+		} else {
+			http.Error(w, "failed to retrieve data from the external API.", http.StatusBadGateway)
+		}
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, neighbors, "neighbors")
+}
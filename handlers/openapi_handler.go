@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"country-info-service/middleware"
+	"country-info-service/openapi"
+)
+
+// OpenAPIHandler handles requests for the OpenAPI 3.1 document describing every endpoint, its parameters and its
+// response types. The paths in it are relative to the server URL /countryinfo/{version}.
+//
+// Endpoint: GET /openapi.json
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	writeBody(w, r, "application/json", openapi.Spec)
+}
+
+// SwaggerUIHandler handles requests for a Swagger UI page to browse and try out the endpoints described by
+// /openapi.json. The page loads Swagger UI from SwaggerUIAssetHandler.
+//
+// Endpoint: GET /docs
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+func SwaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	writeBody(w, r, "text/html; charset=utf-8", openapi.SwaggerUI)
+}
+
+// swaggerUIContentTypes are the content types of the Swagger UI assets, by file extension.
+var swaggerUIContentTypes = map[string]string{
+	".js":  "text/javascript; charset=utf-8",
+	".css": "text/css; charset=utf-8",
+}
+
+// SwaggerUIAssetHandler handles requests for the Swagger UI script and styles used by /docs, which are bundled
+// with the service.
+//
+// Endpoint: GET /docs/{file}
+//
+// Parameters:
+//   - file: (string) "swagger-ui-bundle.js" or "swagger-ui.css".
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 404 Not Found: No such file.
+func SwaggerUIAssetHandler(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	body, err := openapi.SwaggerUIAssets.ReadFile("swagger-ui/" + file)
+	if err != nil {
+		middleware.WriteProblem(w, r, http.StatusNotFound, "No endpoint at this path. See /openapi.json for the endpoints.")
+		return
+	}
+	writeBody(w, r, swaggerUIContentTypes[path.Ext(file)], body)
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"country-info-service/middleware"
+	"country-info-service/openapi"
+	"country-info-service/utils"
+)
+
+// spec returns the OpenAPI document as decoded JSON.
+func spec(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(openapi.Spec, &doc); err != nil {
+		t.Fatalf("decoding the OpenAPI document: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	doc := spec(t)
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.1.") {
+		t.Fatalf("openapi = %q, want 3.1.x", version)
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+
+	// Every route has an operation, and every operation has a route
+	routes := map[string]bool{}
+	for _, rt := range append(v1Routes(), adminRoutes()...) {
+		routes[rt.pattern] = true
+	}
+	for pattern := range routes {
+		method, path, _ := strings.Cut(pattern, " ")
+		item, _ := paths[path].(map[string]interface{})
+		if _, ok := item[strings.ToLower(method)]; !ok {
+			t.Errorf("%s is not in the OpenAPI document", pattern)
+		}
+	}
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			if method == "parameters" || method == "servers" {
+				continue
+			}
+			if pattern := strings.ToUpper(method) + " " + path; !routes[pattern] {
+				t.Errorf("the OpenAPI document has %s, which is not routed", pattern)
+			}
+		}
+	}
+}
+
+func TestOpenAPIHandlers(t *testing.T) {
+	router := NewRouter()
+	for path, contentType := range map[string]string{
+		"/openapi.json":              "application/json",
+		"/docs":                      "text/html; charset=utf-8",
+		"/docs/swagger-ui-bundle.js": "text/javascript; charset=utf-8",
+		"/docs/swagger-ui.css":       "text/css; charset=utf-8",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != contentType {
+			t.Errorf("GET %s: got %d %q, want 200 %q", path, w.Code, w.Header().Get("Content-Type"), contentType)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/swagger-initializer.js", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /docs/swagger-initializer.js: got %d, want 404 for a file that is not bundled", w.Code)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper answering requests with a function.
+type roundTripFunc func(*http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r), nil
+}
+
+// fakeUpstream answers the requests the service makes to REST Countries and CountriesNow, from the sample
+// dataset of the restcountries package and a few CountriesNow responses about Iceland.
+func fakeUpstream(t *testing.T) http.RoundTripper {
+	t.Helper()
+	all, err := os.ReadFile("../restcountries/testdata/sample_all.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var countries []map[string]interface{}
+	if err := json.Unmarshal(all, &countries); err != nil {
+		t.Fatal(err)
+	}
+
+	icelandCounts := `[{"year":2000,"value":281205},{"year":2010,"value":318041},{"year":2018,"value":352721}]`
+	countriesNow := map[string]string{
+		"GET countries":                           `{"error":false,"msg":"countries retrieved","data":[]}`,
+		"GET countries/population":                `{"error":false,"msg":"all countries and population","data":[{"country":"Iceland","iso3":"ISL","populationCounts":` + icelandCounts + `},{"country":"Lesotho","iso3":"LSO","populationCounts":[{"year":2000,"value":1998630},{"year":2018,"value":2108132}]}]}`,
+		"POST countries/population":               `{"error":false,"msg":"Iceland with population","data":{"country":"Iceland","code":"ISL","iso3":"ISL","populationCounts":` + icelandCounts + `}}`,
+		"POST countries/cities":                   `{"error":false,"msg":"cities in Iceland retrieved","data":["Reykjavik","Kopavogur","Akureyri"]}`,
+		"POST countries/states":                   `{"error":false,"msg":"states in Iceland retrieved","data":{"name":"Iceland","states":[{"name":"Capital Region","state_code":"1"},{"name":"Northeastern Region","state_code":"6"}]}}`,
+		"POST countries/population/cities/filter": `{"error":false,"msg":"filtered data","data":[{"city":"Reykjavik","populationCounts":[{"year":"2019","value":"131136","sex":"Both Sexes"}]}]}`,
+	}
+
+	respond := func(status int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	}
+	return roundTripFunc(func(r *http.Request) *http.Response {
+		switch {
+		case r.URL.Path == "/v3.1/all":
+			return respond(http.StatusOK, string(all))
+		case strings.HasPrefix(r.URL.Path, "/v3.1/alpha/"):
+			code := strings.ToUpper(strings.TrimPrefix(r.URL.Path, "/v3.1/alpha/"))
+			for _, country := range countries {
+				if country["cca2"] == code || country["cca3"] == code {
+					body, _ := json.Marshal([]interface{}{country})
+					return respond(http.StatusOK, string(body))
+				}
+			}
+			return respond(http.StatusNotFound, `{"status":404,"message":"Not Found"}`)
+		case strings.HasPrefix(r.URL.Path, "/api/v0.1/"):
+			var payload struct {
+				Country string `json:"country"`
+				State   string `json:"state"`
+			}
+			if r.Body != nil {
+				json.NewDecoder(r.Body).Decode(&payload)
+			}
+			endpoint := strings.TrimPrefix(r.URL.Path, "/api/v0.1/")
+			if r.Method == http.MethodPost && payload.Country != "Iceland" {
+				return respond(http.StatusNotFound, `{"error":true,"msg":"country not found"}`)
+			}
+			if r.Method == http.MethodPost && endpoint == "countries/state/cities" {
+				if payload.State == "Capital Region" {
+					return respond(http.StatusOK, `{"error":false,"msg":"cities retrieved","data":["Reykjavik","Kopavogur"]}`)
+				}
+				return respond(http.StatusOK, `{"error":false,"msg":"cities retrieved","data":["Akureyri"]}`)
+			}
+			if body, ok := countriesNow[r.Method+" "+endpoint]; ok {
+				return respond(http.StatusOK, body)
+			}
+		}
+		return respond(http.StatusNotFound, `{"error":true,"msg":"not found"}`)
+	})
+}
+
+// responseSchema compiles the schema the OpenAPI document gives for a response of an operation, with a
+// status code and media type. Operations without a response for the status, and responses without the media
+// type, fail the test.
+func responseSchema(t *testing.T, compiler *jsonschema.Compiler, doc map[string]interface{}, path, method string, status int, mediaType string) *jsonschema.Schema {
+	t.Helper()
+	escape := strings.NewReplacer("~", "~0", "/", "~1").Replace
+
+	item, _ := doc["paths"].(map[string]interface{})[path].(map[string]interface{})
+	operation, _ := item[strings.ToLower(method)].(map[string]interface{})
+	responses, _ := operation["responses"].(map[string]interface{})
+	response, ok := responses[strconv.Itoa(status)].(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s %s has no %d response in the OpenAPI document", method, path, status)
+	}
+	pointer := "/paths/" + escape(path) + "/" + strings.ToLower(method) + "/responses/" + strconv.Itoa(status)
+	if ref, ok := response["$ref"].(string); ok {
+		pointer = strings.TrimPrefix(ref, "#")
+		name := pointer[strings.LastIndex(pointer, "/")+1:]
+		response = doc["components"].(map[string]interface{})["responses"].(map[string]interface{})[name].(map[string]interface{})
+	}
+	content, _ := response["content"].(map[string]interface{})
+	if _, ok := content[mediaType]; !ok {
+		t.Fatalf("the %d response of %s %s has no %s content in the OpenAPI document", status, method, path, mediaType)
+	}
+
+	schema, err := compiler.Compile("openapi.json#" + pointer + "/content/" + escape(mediaType) + "/schema")
+	if err != nil {
+		t.Fatalf("compiling the schema of the %d response of %s %s: %v", status, method, path, err)
+	}
+	return schema
+}
+
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	// Serve the upstream APIs from the test data, starting from an empty cache
+	previousTransport, previousSnapshot, previousAuth, previousReload := http.DefaultTransport, utils.CountriesSnapshotFile(), Auth, ReloadConfig
+	defer func() {
+		http.DefaultTransport, Auth, ReloadConfig = previousTransport, previousAuth, previousReload
+		utils.SetCountriesSnapshotFile(previousSnapshot)
+		utils.PurgeCache()
+	}()
+	http.DefaultTransport = fakeUpstream(t)
+	utils.SetCountriesSnapshotFile("")
+	utils.PurgeCache()
+
+	auth, err := middleware.NewAuthenticator(middleware.AuthConfig{
+		Keys: []middleware.APIKeyConfig{{Name: "ops", Key: "admin-secret", Admin: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Auth = auth
+	ReloadConfig = func() []error { return nil }
+	handler := auth.Middleware(NewRouter())
+
+	doc := spec(t)
+	compiler := jsonschema.NewCompiler()
+	resource, err := jsonschema.UnmarshalJSON(bytes.NewReader(openapi.Spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compiler.AddResource("openapi.json", resource); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		path   string // Path of the operation in the OpenAPI document; empty for requests no endpoint matches
+		want   int
+	}{
+		{http.MethodGet, "/countryinfo/v1/info/is", "", "/info/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/info/ls?expand=borders&limit=1", "", "/info/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/info/is?fields=name,capital,cities", "", "/info/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/info/is?format=geojson&point=capital", "", "/info/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/info/iceland", "", "/info/{code}", http.StatusBadRequest},
+		{http.MethodGet, "/countryinfo/v1/info/is/neighbors?depth=2", "", "/info/{code}/neighbors", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/population/is", "", "/population/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/population/is?limit=2005-2020", "", "/population/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/population/is?limit=1800-1850", "", "/population/{code}", http.StatusBadRequest},
+		{http.MethodGet, "/countryinfo/v1/status", "", "/status", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/cities/is?limit=2", "", "/cities/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/cities/is?state=Capital%20Region", "", "/cities/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/states/is", "", "/states/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/rankings", "", "/rankings", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/rankings?by=growth&years=2000-2018", "", "/rankings", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/rankings?by=density&format=geojson", "", "/rankings", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/rankings?region=Atlantis", "", "/rankings", http.StatusNotFound},
+		{http.MethodGet, "/countryinfo/v1/route?from=LS&to=LSO", "", "/route", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/route?from=IS&to=LS", "", "/route", http.StatusNotFound},
+		{http.MethodGet, "/countryinfo/v1/landmasses?islands=true", "", "/landmasses", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/islands", "", "/islands", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/enclaves", "", "/enclaves", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/search?q=icland", "", "/search", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/search", "", "/search", http.StatusBadRequest},
+		{http.MethodGet, "/countryinfo/v1/currencies", "", "/currencies", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/currencies/isk", "", "/currencies/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/currencies/xyz", "", "/currencies/{code}", http.StatusNotFound},
+		{http.MethodGet, "/countryinfo/v1/convert?amount=100&from=EUR&to=NOK", "", "/convert", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/convert?amount=1000000&from=USD&perCapita=IS", "", "/convert", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/languages", "", "/languages", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/languages/isl", "", "/languages/{code}", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/nearby?lat=64&lng=-20&radius=1000", "", "/nearby", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/nearby?lat=64&lng=-20&format=geojson", "", "/nearby", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/bbox?minLat=60&minLng=-30&maxLat=70&maxLng=-10&format=geojson&borders=true", "", "/bbox", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/bbox?minLat=60&minLng=-30&maxLat=70&maxLng=-10", "", "/bbox", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/distance?from=IS&to=LS", "", "/distance", http.StatusOK},
+		{http.MethodGet, "/countryinfo/v1/distance?from=IS&to=AQ", "", "/distance", http.StatusNotFound},
+
+		{http.MethodGet, "/countryinfo/admin/usage", "", "/admin/usage", http.StatusOK},
+		{http.MethodGet, "/countryinfo/admin/cache?filter=alpha", "", "/admin/cache", http.StatusOK},
+		{http.MethodPost, "/countryinfo/admin/cache/is", "", "/admin/cache/{code}", http.StatusOK},
+		{http.MethodPost, "/countryinfo/admin/cache/xx", "", "/admin/cache/{code}", http.StatusNotFound},
+		{http.MethodDelete, "/countryinfo/admin/cache/is", "", "/admin/cache/{code}", http.StatusOK},
+		{http.MethodDelete, "/countryinfo/admin/cache", "", "/admin/cache", http.StatusOK},
+		{http.MethodPost, "/countryinfo/admin/reload", "", "/admin/reload", http.StatusOK},
+		{http.MethodGet, "/countryinfo/admin/maintenance", "", "/admin/maintenance", http.StatusOK},
+		{http.MethodPut, "/countryinfo/admin/maintenance", `{"enabled": false}`, "/admin/maintenance", http.StatusOK},
+		{http.MethodPut, "/countryinfo/admin/maintenance", `{"on": true}`, "/admin/maintenance", http.StatusBadRequest},
+
+		{http.MethodGet, "/countryinfo/v1/countries", "", "", http.StatusNotFound},
+		{http.MethodPost, "/countryinfo/v1/search?q=norway", "", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if strings.Contains(tt.target, "/admin/") {
+			r.Header.Set("X-API-Key", "admin-secret")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d: %s", tt.method, tt.target, w.Code, tt.want, w.Body)
+			continue
+		}
+
+		mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		if err != nil {
+			t.Errorf("%s %s: Content-Type %q: %v", tt.method, tt.target, w.Header().Get("Content-Type"), err)
+			continue
+		}
+		var schema *jsonschema.Schema
+		if tt.path == "" {
+			schema, err = compiler.Compile("openapi.json#/components/schemas/Problem")
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			schema = responseSchema(t, compiler, doc, tt.path, tt.method, w.Code, mediaType)
+		}
+
+		// Plain-text errors are validated as a string, everything else as the JSON it is
+		var instance interface{} = w.Body.String()
+		if mediaType != "text/plain" {
+			if instance, err = jsonschema.UnmarshalJSON(w.Body); err != nil {
+				t.Errorf("%s %s: decoding the %s response: %v", tt.method, tt.target, mediaType, err)
+				continue
+			}
+		}
+		if err := schema.Validate(instance); err != nil {
+			t.Errorf("%s %s: the response does not match the OpenAPI document: %v", tt.method, tt.target, err)
+		}
+	}
+}
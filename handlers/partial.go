@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"country-info-service/utils"
+)
+
+// partialResponseHeader lists the resources missing from a partial response.
+const partialResponseHeader = "X-Partial-Response"
+
+// setPartialResponseHeader marks a response as partial when some of its sub-resources could not be
+// retrieved, listing them in the X-Partial-Response header. The details are in the "warnings" field.
+// Partial responses must be revalidated before reuse, so that caches pick up the complete response once
+// the missing data is available.
+func setPartialResponseHeader(w http.ResponseWriter, warnings []utils.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	resources := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		resources = append(resources, warning.Resource)
+	}
+	w.Header().Set(partialResponseHeader, strings.Join(resources, ", "))
+	setNoCache(w)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -14,32 +13,31 @@ import (
 
 // PopulationHandler handles requests for country population data based on an ISO2 country code and optional year range.
 //
-// Endpoint: GET /countryinfo/v1/population/{countryCode}?limit={startYear-endYear}
+// Endpoint: GET /countryinfo/v1/population/{countryCode}?limit={startYear-endYear}&format={json|csv|xml|yaml|ndjson}
 //
 // Parameters:
 //   - countryCode: (string) The ISO2 country code (e.g., "NO" for Norway).
 //   - limit (optional): (string) A year range in the format "startYear-endYear" (e.g., "2000-2020"). Has to be valid 4 digit year counts.
+//   - format (optional): (string) "json" (default), "csv" (year,value rows), "xml", "yaml", "ndjson" (a line per year)
+//     or the Accept header equivalent.
 //
 // Example Requests:
 //   - GET /countryinfo/v1/population/NO
 //   - GET /countryinfo/v1/population/US?limit=2000-2010
+//   - GET /countryinfo/v1/population/NO?format=csv
 //
 // Response:
 //   A JSON object containing population data with mean value and an array of year-value pairs.
 //
 // Possible HTTP Status Codes:
 //   - 200 OK: Request was successful.
-//   - 400 Bad Request: Missing or invalid country code, or invalid query parameters.
+//   - 400 Bad Request: Invalid country code, or invalid query parameters.
 //   - 404 Not Found: No population data available for the specified country or year range.
+//   - 406 Not Acceptable: The Accept header allows none of the supported formats.
 //   - 502 Bad Gateway: External API failure.
 func PopulationHandler(w http.ResponseWriter, r *http.Request) {
 	// Gets country code and validate it
-	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/countryinfo/v1/population/"), "/")
-	if len(parts) < 1 || parts[0] == "" {
-		http.Error(w, "Missing country code. Example: /countryinfo/v1/population/NO", http.StatusBadRequest)
-		return
-	}
-	countryCode := strings.ToUpper(parts[0]) // Convert to uppercase for consistency and easier comparison
+	countryCode := strings.ToUpper(r.PathValue("code")) // Convert to uppercase for consistency and easier comparison
 
 	// Check if the country code is in ISO2 format
 	if matched, _ := regexp.MatchString("^[A-Z]{2}$", countryCode); !matched {
@@ -94,6 +92,6 @@ func PopulationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
+	setLastModified(w, data.FetchedAt)
+	writeListResponse(w, r, data, "values")
 }
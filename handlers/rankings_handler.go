@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"country-info-service/utils"
+)
+
+// RankingsHandler handles requests for ranking all countries, or the countries of a region,
+// by population, population growth or population density.
+//
+// Endpoint: GET /countryinfo/v1/rankings?by={criterion}&region={region}&years={startYear-endYear}&order={asc|desc}&limit={number}&offset={number}&format={json|csv|xml|yaml|ndjson|geojson}
+//
+// Parameters:
+//   - by (optional): (string) "population" (default), "growth" or "density" (inhabitants per km²).
+//   - region (optional): (string) A region or subregion to rank within (e.g., "Europe", "Northern Europe").
+//   - years (optional): (string) Year range used for growth, in the format "startYear-endYear" (e.g., "2000-2018").
+//     If not provided, each country's earliest and latest recorded years are used.
+//   - order (optional): (string) "desc" (default, largest first) or "asc".
+//   - limit (optional): (int) The maximum number of countries to return (default: 20).
+//   - offset (optional): (int) The number of ranked countries to skip (default: 0).
+//   - format (optional): (string) "json" (default), "csv" (a row per country), "xml", "yaml", "ndjson" (a line per
+//     country) or the Accept header equivalent. "geojson" gives a GeoJSON FeatureCollection with a feature per
+//     ranked country and the ranking entry as its properties. See writeGeoJSON for the "point" and "borders" options.
+//
+// Example Requests:
+//   - GET /countryinfo/v1/rankings
+//   - GET /countryinfo/v1/rankings?by=density&region=europe&limit=5
+//   - GET /countryinfo/v1/rankings?by=growth&years=2000-2018&order=asc&offset=20
+//   - GET /countryinfo/v1/rankings?region=europe&limit=50&format=geojson
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful.
+//   - 400 Bad Request: Invalid query parameter.
+//   - 404 Not Found: No countries found for the given region.
+//   - 502 Bad Gateway: External API failure.
+func RankingsHandler(w http.ResponseWriter, r *http.Request) {
+	query := utils.RankingQuery{By: utils.RankByPopulation, Limit: 20}
+	params := r.URL.Query()
+
+	// Validate ranking criterion
+	if by := strings.ToLower(params.Get("by")); by != "" {
+		if by != utils.RankByPopulation && by != utils.RankByGrowth && by != utils.RankByDensity {
+			http.Error(w, "Invalid 'by' parameter. Use 'population', 'growth' or 'density'.", http.StatusBadRequest)
+			return
+		}
+		query.By = by
+	}
+	query.Region = params.Get("region")
+
+	// Parse optional year range used for growth
+	if yearsParam := params.Get("years"); yearsParam != "" {
+		years := strings.Split(yearsParam, "-")
+		if len(years) != 2 {
+			http.Error(w, "Invalid 'years' format. Use 'startYear-endYear'.", http.StatusBadRequest)
+			return
+		}
+		var err1, err2 error
+		query.StartYear, err1 = strconv.Atoi(years[0])
+		query.EndYear, err2 = strconv.Atoi(years[1])
+		if err1 != nil || err2 != nil {
+			http.Error(w, "Invalid 'years' format. Use 'startYear-endYear' with numeric values (e.g., '2000-2018').", http.StatusBadRequest)
+			return
+		}
+		currentYear := time.Now().Year()
+		if query.StartYear < 1900 || query.EndYear > currentYear || query.StartYear >= query.EndYear {
+			http.Error(w, fmt.Sprintf("Year range out of bounds. Use increasing years between 1900 and %d.", currentYear), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Validate sort order
+	switch strings.ToLower(params.Get("order")) {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		http.Error(w, "Invalid 'order' parameter. Use 'asc' or 'desc'.", http.StatusBadRequest)
+		return
+	}
+
+	// Parse pagination
+	if limitParam := params.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Invalid 'limit' parameter. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+	if offsetParam := params.Get("offset"); offsetParam != "" {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid 'offset' parameter. Must be a non-negative integer.", http.StatusBadRequest)
+			return
+		}
+		query.Offset = offset
+	}
+
+	// Fetch rankings
+	rankings, err := utils.FetchRankings(query)
+	if err != nil {
+		fmt.Println("Error fetching rankings:", err)
+
+		// Differentiate error types
+		if strings.Contains(err.Error(), "no countries found") {
+			http.Error(w, "No countries found for the given region.", http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "API returned status") || strings.Contains(err.Error(), "failed to fetch") {
+			http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		} else {
+			http.Error(w, "Internal server error while ranking countries.", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Send response
+	if wantsGeoJSON(r) {
+		sources := make([]utils.FeatureSource, 0, len(rankings.Results))
+		for _, entry := range rankings.Results {
+			sources = append(sources, utils.FeatureSource{Code: entry.ISO3, Properties: entry})
+		}
+		writeGeoJSON(w, r, sources)
+		return
+	}
+	writeListResponse(w, r, rankings, "results")
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"country-info-service/render"
+)
+
+// writeResponse sends v in the format chosen by the "format" query parameter or the Accept header:
+// JSON (default), CSV, XML or YAML.
+func writeResponse(w http.ResponseWriter, r *http.Request, v interface{}) {
+	writeFormatted(w, r, v, "")
+}
+
+// writeListResponse sends a response holding a list, like writeResponse. list is the JSON name of the field
+// holding the list: in CSV each item is a row, and NDJSON is also available, with an item per line.
+func writeListResponse(w http.ResponseWriter, r *http.Request, v interface{}, list string) {
+	writeFormatted(w, r, v, list)
+}
+
+// writeFormatted negotiates the format and encodes v. The response is encoded before anything is written,
+// so that encoding errors can still be reported with a status code and the ETag can be computed.
+func writeFormatted(w http.ResponseWriter, r *http.Request, v interface{}, list string) {
+	w.Header().Add("Vary", "Accept")
+
+	format, err := render.Default.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"), list != "")
+	if errors.Is(err, render.ErrNotAcceptable) {
+		http.Error(w, fmt.Sprintf("None of the accepted media types is supported. Use ?format= with one of: %s.", strings.Join(render.Default.Names(list != ""), ", ")), http.StatusNotAcceptable)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid 'format' parameter: %v.", err), http.StatusBadRequest)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := format.Encode(&body, v, list); err != nil {
+		fmt.Println("Error encoding response:", err)
+		http.Error(w, "Internal server error while encoding data.", http.StatusInternalServerError)
+		return
+	}
+
+	writeBody(w, r, format.ContentType, body.Bytes())
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"country-info-service/middleware"
+)
+
+// v1Deprecation holds the deprecation of v1 announced in the headers of its responses. It announces nothing
+// until set with SetV1Deprecation.
+var v1Deprecation atomic.Pointer[middleware.Deprecation]
+
+// SetV1Deprecation sets the deprecation of v1 announced in the headers of its responses. It may be called while
+// requests are served.
+func SetV1Deprecation(deprecation middleware.Deprecation) {
+	v1Deprecation.Store(&deprecation)
+}
+
+// route is an endpoint of an API version: a method and path pattern below /countryinfo/{version}, and its handler.
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// NewRouter returns the handler routing every endpoint of the service by method and path pattern. The API is
+// mounted under /countryinfo/v1; a later version is mounted next to it and shares the utils layer. The admin
+// endpoints are not versioned and are served once, under /countryinfo/admin.
+// Paths are matched exactly: path parameters are a single segment, and there are no trailing-slash variants.
+// Requests for paths without an endpoint get a 404 problem response, and requests with a method the endpoint
+// does not support get a 405 problem response with an Allow header. The OpenAPI document describing the endpoints
+// is served at /openapi.json, and a Swagger UI page to browse it at /docs, with its script and styles under /docs/.
+func NewRouter() http.Handler {
+	mux := http.NewServeMux()
+	mount(mux, "v1", v1Routes(), &v1Deprecation)
+	for _, rt := range adminRoutes() {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		mux.Handle(method+" /countryinfo"+path, rt.handler)
+	}
+	mux.HandleFunc("GET /openapi.json", OpenAPIHandler)
+	mux.HandleFunc("GET /docs", SwaggerUIHandler)
+	mux.HandleFunc("GET /docs/{file}", SwaggerUIAssetHandler)
+	return &router{mux: mux}
+}
+
+// v1Routes returns the endpoints of v1.
+func v1Routes() []route {
+	return []route{
+		{"GET /info/{code}", CountryInfoHandler},
+		{"GET /info/{code}/neighbors", NeighborsHandler},
+		{"GET /population/{code}", PopulationHandler},
+		{"GET /status", StatusHandler},
+		{"GET /cities/{code}", CitiesHandler},
+		{"GET /states/{code}", StatesHandler},
+		{"GET /rankings", RankingsHandler},
+		{"GET /route", RouteHandler},
+		{"GET /landmasses", LandmassesHandler},
+		{"GET /islands", IslandsHandler},
+		{"GET /enclaves", EnclavesHandler},
+		{"GET /search", SearchHandler},
+		{"GET /currencies", CurrenciesHandler},
+		{"GET /currencies/{code}", CurrenciesHandler},
+		{"GET /convert", ConvertHandler},
+		{"GET /languages", LanguagesHandler},
+		{"GET /languages/{code}", LanguagesHandler},
+		{"GET /nearby", NearbyHandler},
+		{"GET /bbox", BoundingBoxHandler},
+		{"GET /distance", DistanceHandler},
+	}
+}
+
+// adminRoutes returns the admin endpoints, whose patterns are below /countryinfo rather than an API version.
+func adminRoutes() []route {
+	return []route{
+		{"GET /admin/usage", UsageHandler},
+		{"GET /admin/cache", CacheHandler},
+		{"DELETE /admin/cache", CacheHandler},
+		{"DELETE /admin/cache/{code}", CountryCacheHandler},
+		{"POST /admin/cache/{code}", CountryCacheHandler},
+		{"POST /admin/reload", ReloadHandler},
+		{"GET /admin/maintenance", MaintenanceHandler},
+		{"PUT /admin/maintenance", MaintenanceHandler},
+	}
+}
+
+// mount registers the routes of an API version under /countryinfo/{version}. The handlers can tell which
+// version was requested with apiVersion. If deprecation is set, the responses announce it.
+func mount(mux *http.ServeMux, version string, routes []route, deprecation *atomic.Pointer[middleware.Deprecation]) {
+	for _, rt := range routes {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		var handler http.Handler = withVersion(version, rt.handler)
+		if deprecation != nil {
+			handler = middleware.Deprecate(handler, deprecation)
+		}
+		mux.Handle(method+" /countryinfo/"+version+path, handler)
+	}
+}
+
+// versionKey is the type of the request context key holding the requested API version.
+type versionKey struct{}
+
+// withVersion makes the API version available to the handler through apiVersion.
+func withVersion(version string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+	})
+}
+
+// apiVersion returns the API version of a request (e.g., "v1").
+func apiVersion(r *http.Request) string {
+	if version, ok := r.Context().Value(versionKey{}).(string); ok {
+		return version
+	}
+	return "v1"
+}
+
+// router serves requests with a ServeMux, replacing its plain-text 404 and 405 responses with problem responses.
+type router struct {
+	mux *http.ServeMux
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+
+	// No pattern matches. Let the ServeMux tell a missing path from a method it does not support.
+	var unmatched unmatchedResponse
+	rt.mux.ServeHTTP(&unmatched, r)
+	if unmatched.status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", unmatched.header.Get("Allow"))
+		middleware.WriteProblem(w, r, http.StatusMethodNotAllowed,
+			fmt.Sprintf("Method %s is not allowed. Use %s.", r.Method, unmatched.header.Get("Allow")))
+		return
+	}
+	middleware.WriteProblem(w, r, http.StatusNotFound, "No endpoint at this path. See /openapi.json for the endpoints.")
+}
+
+// unmatchedResponse records the status and headers of the response the ServeMux gives when no pattern matches,
+// discarding the body.
+type unmatchedResponse struct {
+	header http.Header
+	status int
+}
+
+func (u *unmatchedResponse) Header() http.Header {
+	if u.header == nil {
+		u.header = http.Header{}
+	}
+	return u.header
+}
+
+func (u *unmatchedResponse) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (u *unmatchedResponse) WriteHeader(status int) {
+	u.status = status
+}
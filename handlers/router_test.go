@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"country-info-service/middleware"
+	"country-info-service/utils"
+)
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+
+	// None of these reach an upstream API: they are rejected by the router or by the handler's validation
+	tests := []struct {
+		method string
+		path   string
+		want   int
+		allow  string
+	}{
+		{http.MethodGet, "/countryinfo/v1/info/norway", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/info/no/neighbors?depth=9", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/currencies/euro", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/convert?amount=NaN&from=EUR&to=NOK", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/convert?amount=Inf&from=EUR&to=NOK", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/convert?amount=1e308&from=EUR&to=NOK", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/nearby?lat=NaN&lng=10", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/nearby?lat=60&lng=-Inf", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/nearby?lat=60&lng=10&radius=NaN", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/nearby?lat=60&lng=10&radius=Inf", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/bbox?minLat=50&minLng=NaN&maxLat=60&maxLng=10", http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/search?q=" + strings.Repeat("å", 101), http.StatusBadRequest, ""},
+		{http.MethodGet, "/countryinfo/v1/info/no/garbage", http.StatusNotFound, ""},
+		{http.MethodGet, "/countryinfo/v1/info/", http.StatusNotFound, ""},
+		{http.MethodGet, "/countryinfo/v1/info/no/", http.StatusNotFound, ""},
+		{http.MethodGet, "/countryinfo/v1/rankings/", http.StatusNotFound, ""},
+		{http.MethodGet, "/countryinfo/v2/info/no", http.StatusNotFound, ""},
+		{http.MethodGet, "/countryinfo/v3/info/no", http.StatusNotFound, ""},
+		{http.MethodPost, "/countryinfo/v1/info/no", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodDelete, "/countryinfo/v1/status", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodPatch, "/countryinfo/admin/cache", http.StatusMethodNotAllowed, "DELETE, GET, HEAD"},
+		{http.MethodDelete, "/countryinfo/admin/cache/no", http.StatusUnauthorized, ""},
+		{http.MethodGet, "/countryinfo/v1/admin/usage", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+		if got := w.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", tt.method, tt.path, got, tt.allow)
+		}
+		if tt.want == http.StatusNotFound || tt.want == http.StatusMethodNotAllowed {
+			var problem middleware.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || problem.Status != tt.want {
+				t.Errorf("%s %s: expected a problem response, got %+v (err %v)", tt.method, tt.path, problem, err)
+			}
+			if w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("%s %s: Content-Type = %q", tt.method, tt.path, w.Header().Get("Content-Type"))
+			}
+		}
+	}
+}
+
+func TestRouterVersions(t *testing.T) {
+	previous := v1Deprecation.Load()
+	defer v1Deprecation.Store(previous)
+	SetV1Deprecation(middleware.Deprecation{Sunset: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)})
+	router := NewRouter()
+
+	// The status endpoint reports the version requested. Maintenance mode keeps it from checking the upstream APIs.
+	utils.SetMaintenance(true)
+	defer utils.SetMaintenance(false)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/countryinfo/v1/status", nil))
+	var status APIStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil || status.Version != "v1" {
+		t.Errorf("status: got version %q (err %v)", status.Version, err)
+	}
+
+	// v1 announces its sunset, and the admin endpoints, which are not versioned, do not
+	for path, want := range map[string]string{
+		"/countryinfo/v1/info/norway": "Tue, 01 Jan 2030 00:00:00 GMT",
+		"/countryinfo/admin/usage":    "",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := w.Header().Get("Sunset"); got != want {
+			t.Errorf("%s: Sunset = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"country-info-service/utils"
+)
+
+// maxSearchQueryLength is the longest query accepted, in characters. Typo tolerance compares the query with
+// every name, at a cost growing with its length.
+const maxSearchQueryLength = 100
+
+// SearchHandler handles fuzzy searches for countries by name, so users can type names instead of ISO codes.
+// The query is matched against common, official, native and alternative names and translations,
+// ignoring case and accents and tolerating typos.
+//
+// Endpoint: GET /countryinfo/v1/search?q={text}&limit={number}
+//
+// Parameters:
+//   - q: (string) The (partial) country name to search for (e.g., "norw"), at most 100 characters.
+//   - limit (optional): (int) The maximum number of matches to return, between 1 and 50 (default: 10).
+//
+// Example Requests:
+//   - GET /countryinfo/v1/search?q=norw
+//   - GET /countryinfo/v1/search?q=germnay&limit=3
+//
+// Response:
+//   A JSON object with the query and the matching countries, best match first. Each match includes the name
+//   that matched, what kind of name it is (common, official, native, alternative or translation) and a score
+//   between 0 and 1.
+//
+// Possible HTTP Status Codes:
+//   - 200 OK: Request was successful (possibly with no matches).
+//   - 400 Bad Request: Missing query or invalid query parameter.
+//   - 502 Bad Gateway: External API failure.
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Missing 'q' parameter. Example: /countryinfo/v1/search?q=norw", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		http.Error(w, fmt.Sprintf("Invalid 'q' parameter. Must be at most %d characters.", maxSearchQueryLength), http.StatusBadRequest)
+		return
+	}
+
+	// Extract the "limit" query parameter, defaulting to 10 if not provided
+	limit := 10
+	if queryLimit := r.URL.Query().Get("limit"); queryLimit != "" {
+		parsedLimit, err := strconv.Atoi(queryLimit)
+		if err != nil || parsedLimit <= 0 || parsedLimit > 50 {
+			http.Error(w, "Invalid 'limit' parameter. Must be an integer between 1 and 50.", http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	// Search countries
+	results, err := utils.FetchSearch(query, limit)
+	if err != nil {
+		fmt.Println("Error searching countries:", err)
+		http.Error(w, "Failed to retrieve data from the external API.", http.StatusBadGateway)
+		return
+	}
+
+	// Send response
+	writeListResponse(w, r, results, "results")
+}
@@ -1,23 +1,26 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 	"time"
 	"log"
+
+	"country-info-service/utils"
 )
 
 // The status handler provides real-time service diagnostics from the API endpoints used in the country-info-service.
 // It checks the health status of the CountriesNow API and the RestCountries API.
 // The uptime of the service is also calculated and returned in the response.
+// In upstream maintenance mode the APIs are not checked, and their status is "MAINTENANCE".
+// If the cache warm-up was started, its progress is included.
 //
-// Endpoint: GET /countryinfo/v1/status
+// Endpoint: GET /countryinfo/{version}/status
 //
 // Example Request:
 //   GET /countryinfo/v1/status
 //
 // Response:
-//   A JSON object containing the health status of the APIs, the current version and the service uptime in seconds.
+//   A JSON object containing the health status of the APIs, the requested API version and the service uptime in seconds.
 //
 // Possible HTTP Status Codes:
 //   - 200 OK: Request was successful.
@@ -28,7 +31,16 @@ import (
 //     "countriesnowapi": "200",
 //     "restcountriesapi": "200",
 //     "version": "v1",
-//     "uptime": 128
+//     "uptime": 128,
+//     "maintenance": false,
+//     "warmup": {
+//       "state": "done",
+//       "startedAt": "2024-01-02T12:00:00Z",
+//       "finishedAt": "2024-01-02T12:00:04Z",
+//       "total": 21,
+//       "completed": 21,
+//       "failed": 0
+//     }
 //   }
 
 
@@ -37,10 +49,12 @@ var startTime = time.Now()
 
 // APIStatus represents the health status of an API
 type APIStatus struct {
-	CountriesNowAPI  string `json:"countriesnowapi"`  // Status of the CountriesNow API
-	RestCountriesAPI string `json:"restcountriesapi"` // Status of the RestCountries API
-	Version          string `json:"version"`          // API version
-	Uptime           int    `json:"uptime"`           // Service uptime in seconds
+	CountriesNowAPI  string                `json:"countriesnowapi"`  // Status of the CountriesNow API
+	RestCountriesAPI string                `json:"restcountriesapi"` // Status of the RestCountries API
+	Version          string                `json:"version"`          // API version
+	Uptime           int                   `json:"uptime"`           // Service uptime in seconds
+	Maintenance      bool                  `json:"maintenance"`      // Whether upstream maintenance mode is on
+	WarmUp           *utils.WarmUpProgress `json:"warmup,omitempty"` // Progress of the cache warm-up, if started
 }
 
 // checkAPIHealth makes a request to an API with a timeout and returns its status
@@ -66,19 +80,24 @@ func checkAPIHealth(url string) string {
 func StatusHandler(w http.ResponseWriter, r *http.Request) {
 	uptime := int(time.Since(startTime).Seconds())
 
-	// Check both APIs
-	countriesNowStatus := checkAPIHealth("http://129.241.150.113:3500/api/v0.1/countries")
-	restCountriesStatus := checkAPIHealth("http://129.241.150.113:8080/v3.1/all")
+	// Check both APIs, unless they are not to be called
+	countriesNowStatus, restCountriesStatus := "MAINTENANCE", "MAINTENANCE"
+	if !utils.Maintenance() {
+		countriesNowStatus = checkAPIHealth("http://129.241.150.113:3500/api/v0.1/countries")
+		restCountriesStatus = checkAPIHealth("http://129.241.150.113:8080/v3.1/all")
+	}
 
 	// Construct JSON response
 	status := APIStatus{
 		CountriesNowAPI:  countriesNowStatus,
 		RestCountriesAPI: restCountriesStatus,
-		Version:          "v1",
+		Version:          apiVersion(r),
 		Uptime:           uptime,
+		Maintenance:      utils.Maintenance(),
+		WarmUp:           utils.WarmUpStatus(),
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	// Send response. The status is live, so it is never reused without asking again.
+	setNoCache(w)
+	writeResponse(w, r, status)
 }
@@ -3,20 +3,211 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
+	"country-info-service/geo"
 	"country-info-service/handlers"
+	"country-info-service/middleware"
+	"country-info-service/rates"
+	"country-info-service/utils"
 )
 
 func main() {
-	// Register handlers
-	http.HandleFunc("/countryinfo/v1/info/", handlers.CountryInfoHandler)
-	http.HandleFunc("/countryinfo/v1/population/", handlers.PopulationHandler)
-	http.HandleFunc("/countryinfo/v1/status/", handlers.StatusHandler)
+	// Configure the smallest response that is compressed, in bytes
+	compressionMinSize := 1024
+	if value := os.Getenv("COMPRESSION_MIN_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			fmt.Println("Invalid COMPRESSION_MIN_SIZE, using the default:", value)
+		} else {
+			compressionMinSize = size
+		}
+	}
+
+	// Apply the settings from environment variables, then start without rate limits and load the
+	// configuration files. Admins can reload the files without a restart.
+	for _, err := range configure() {
+		fmt.Println("Error in configuration:", err)
+	}
+	limiter, _ := middleware.NewRateLimiter(middleware.DefaultRateLimitConfig())
+	for _, err := range loadConfigFiles(limiter) {
+		fmt.Println("Error in configuration:", err)
+	}
+
+	// Authentication fails closed: without the configured API keys every route would be served to anyone,
+	// so the service does not start
+	authConfig, err := loadAuthConfig()
+	if err != nil {
+		fmt.Println("Error in configuration: loading API keys:", err)
+		os.Exit(1)
+	}
+	auth, err := middleware.NewAuthenticator(authConfig)
+	if err != nil {
+		fmt.Println("Error in configuration: invalid API keys:", err)
+		os.Exit(1)
+	}
+	handlers.Auth = auth
+	handlers.ReloadConfig = func() []error { return reloadConfig(limiter, auth) }
+	limiter.ValidAPIKey = auth.Valid
+
+	// Prefetch the country dataset, and optionally the population of the most populous countries, in the
+	// background so that the first requests after a start are fast
+	if os.Getenv("WARMUP") != "false" {
+		warmUp := utils.WarmUpConfig{Concurrency: 4}
+		if value := os.Getenv("WARMUP_POPULATION_TOP_N"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Println("Invalid WARMUP_POPULATION_TOP_N, only prefetching the country dataset:", value)
+			} else {
+				warmUp.PopulationTopN = n
+			}
+		}
+		if value := os.Getenv("WARMUP_CONCURRENCY"); value != "" {
+			concurrency, err := strconv.Atoi(value)
+			if err != nil || concurrency < 1 {
+				fmt.Println("Invalid WARMUP_CONCURRENCY, using the default:", value)
+			} else {
+				warmUp.Concurrency = concurrency
+			}
+		}
+		go utils.WarmUp(warmUp)
+	}
 
 	// Start server
 	fmt.Println("Server is running on port 8080...")
-	err := http.ListenAndServe(":8080", nil)
-	if err != nil {
+	handler := limiter.Middleware(auth.Middleware(middleware.Compress(handlers.NewRouter(), compressionMinSize)))
+	if err := http.ListenAndServe(":8080", handler); err != nil {
 		fmt.Println("Error starting server:", err)
 	}
 }
+
+// configure applies the settings given directly in environment variables. The environment of a running process
+// does not change, so they are only read at startup. Settings that are invalid keep their defaults, and the
+// errors are returned.
+func configure() []error {
+	var errs []error
+
+	// Configure where the REST Countries dataset is saved for offline use, if anywhere
+	if path := os.Getenv("COUNTRIES_SNAPSHOT_FILE"); path != "" {
+		utils.SetCountriesSnapshotFile(path)
+	}
+
+	// Configure how long clients may reuse responses, in seconds
+	if value := os.Getenv("CACHE_MAX_AGE"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			errs = append(errs, fmt.Errorf("invalid CACHE_MAX_AGE %q, keeping %v", value, handlers.CacheMaxAge()))
+		} else {
+			handlers.SetCacheMaxAge(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	// Announce the deprecation and sunset of v1, as dates (e.g., 2025-06-30)
+	deprecation := middleware.Deprecation{Link: os.Getenv("V1_DEPRECATION_LINK")}
+	var deprecationErr error
+	for _, setting := range []struct {
+		name string
+		date *time.Time
+	}{
+		{"V1_DEPRECATION_DATE", &deprecation.At},
+		{"V1_SUNSET_DATE", &deprecation.Sunset},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			date, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				deprecationErr = fmt.Errorf("invalid %s %q, not announcing a v1 deprecation: %w", setting.name, value, err)
+			}
+			*setting.date = date
+		}
+	}
+	if deprecationErr != nil {
+		errs = append(errs, deprecationErr)
+	} else {
+		handlers.SetV1Deprecation(deprecation)
+	}
+
+	return errs
+}
+
+// loadConfigFiles applies the settings read from the files named by environment variables: exchange rates,
+// border polygons and rate limits. It runs at startup and whenever an admin reloads the configuration, which
+// reads the files again. Settings that fail to load keep their current values, and the errors are returned.
+func loadConfigFiles(limiter *middleware.RateLimiter) []error {
+	var errs []error
+
+	// Load exchange rates from a file instead of the bundled stand-in rates
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		provider, err := rates.LoadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading exchange rates, keeping the current rates: %w", err))
+		} else {
+			utils.SetRateProvider(provider)
+		}
+	}
+
+	// Load country border polygons for GeoJSON output, replacing the bundled ones
+	if path := os.Getenv("COUNTRY_BORDERS_FILE"); path != "" {
+		borders, err := geo.LoadBorders(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading country borders, keeping the current borders: %w", err))
+		} else {
+			utils.SetBorders(borders)
+		}
+	}
+
+	// Configure rate limits, per route and client
+	rateLimits := middleware.DefaultRateLimitConfig()
+	if path := os.Getenv("RATE_LIMITS_FILE"); path != "" {
+		config, err := middleware.LoadRateLimitConfig(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading rate limits, keeping the current limits: %w", err))
+		} else {
+			rateLimits = config
+		}
+	}
+	if err := limiter.SetConfig(rateLimits); err != nil {
+		errs = append(errs, fmt.Errorf("invalid rate limits, keeping the current limits: %w", err))
+	}
+
+	return errs
+}
+
+// reloadConfig reads the configuration files again, including the API keys. Unlike at startup, API keys that
+// fail to load keep the current keys, which were loaded successfully before. API keys given in API_KEYS instead
+// of a file are applied again unchanged.
+func reloadConfig(limiter *middleware.RateLimiter, auth *middleware.Authenticator) []error {
+	errs := loadConfigFiles(limiter)
+	authConfig, err := loadAuthConfig()
+	if err != nil {
+		return append(errs, fmt.Errorf("loading API keys, keeping the current keys: %w", err))
+	}
+	if err := auth.SetConfig(authConfig); err != nil {
+		errs = append(errs, fmt.Errorf("invalid API keys, keeping the current keys: %w", err))
+	}
+	return errs
+}
+
+// loadAuthConfig reads the API key configuration, from the file named by API_KEYS_FILE or as name=key pairs in
+// API_KEYS. Without either, no keys are configured.
+func loadAuthConfig() (middleware.AuthConfig, error) {
+	var authConfig middleware.AuthConfig
+	if path := os.Getenv("API_KEYS_FILE"); path != "" {
+		config, err := middleware.LoadAuthConfig(path)
+		if err != nil {
+			return authConfig, err
+		}
+		authConfig = config
+	} else if value := os.Getenv("API_KEYS"); value != "" {
+		keys, err := middleware.ParseAPIKeys(value)
+		if err != nil {
+			return authConfig, fmt.Errorf("parsing API_KEYS: %w", err)
+		}
+		authConfig = middleware.AuthConfig{Required: os.Getenv("API_KEYS_REQUIRED") == "true", Keys: keys}
+	}
+	if authConfig.PublicRoutes == nil {
+		authConfig.PublicRoutes = []string{"/countryinfo/v1/status", "/openapi.json", "/docs"}
+	}
+	return authConfig, nil
+}
@@ -0,0 +1,301 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyConfig describes an API key and what it may do.
+type APIKeyConfig struct {
+	Name       string   `json:"name"`                 // Identifies the key in usage reports; the key itself is never shown
+	Key        string   `json:"key"`                  // The secret sent by the client
+	DailyQuota int      `json:"dailyQuota,omitempty"` // Requests allowed per UTC day; 0 is unlimited
+	Routes     []string `json:"routes,omitempty"`     // Path prefixes the key may access; empty allows all
+	Admin      bool     `json:"admin,omitempty"`      // Whether the key may use the admin endpoints
+}
+
+// AuthConfig configures an Authenticator.
+type AuthConfig struct {
+	// Required rejects requests without an API key. Otherwise keys are optional, but must be valid if sent.
+	Required bool `json:"required"`
+	// PublicRoutes are path prefixes that never require a key.
+	PublicRoutes []string       `json:"publicRoutes"`
+	Keys         []APIKeyConfig `json:"keys"`
+}
+
+// LoadAuthConfig reads an AuthConfig from a JSON file.
+func LoadAuthConfig(path string) (AuthConfig, error) {
+	var config AuthConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse API keys file: %w", err)
+	}
+	return config, nil
+}
+
+// ParseAPIKeys parses API keys from a comma-separated list of name=key pairs (e.g., "dashboard=s3cret,etl=t0ken"),
+// as used in environment variables. Keys given this way have no quota and may access all routes.
+func ParseAPIKeys(value string) ([]APIKeyConfig, error) {
+	keys := []APIKeyConfig{}
+	for i, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, key, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || name == "" || key == "" {
+			// The entry is reported by its position, since it may hold a secret
+			return nil, fmt.Errorf("entry %d is not name=key", i+1)
+		}
+		keys = append(keys, APIKeyConfig{Name: name, Key: key})
+	}
+	return keys, nil
+}
+
+// KeyUsage holds the usage counters of an API key.
+type KeyUsage struct {
+	Name          string           `json:"name"`
+	Admin         bool             `json:"admin"`
+	DailyQuota    int              `json:"dailyQuota,omitempty"`
+	Requests      int64            `json:"requests"`      // Accepted requests in total
+	RequestsToday int              `json:"requestsToday"` // Accepted requests this UTC day, counted against the quota
+	Rejected      int64            `json:"rejected"`      // Requests refused for the quota or a route not allowed
+	LastUsed      *time.Time       `json:"lastUsed,omitempty"`
+	Endpoints     map[string]int64 `json:"endpoints"` // Accepted requests per endpoint (e.g., "/countryinfo/v1/info")
+}
+
+// maxUsageEndpoints is the most endpoints counted separately for a key. Paths are counted before routing, so
+// requests for further endpoints, which may be made-up paths, are counted under otherEndpoints.
+const maxUsageEndpoints = 50
+
+// otherEndpoints is the usage counter of the requests beyond maxUsageEndpoints endpoints.
+const otherEndpoints = "other"
+
+// apiKey is a configured key with its usage.
+type apiKey struct {
+	config APIKeyConfig
+	usage  KeyUsage
+	day    string // UTC day RequestsToday counts
+}
+
+// Authenticator checks API keys and enforces their quotas and allowed routes.
+type Authenticator struct {
+	mu     sync.Mutex
+	config AuthConfig
+	keys   map[[sha256.Size]byte]*apiKey // Keyed by the hash of the secret
+	now    func() time.Time
+}
+
+// NewAuthenticator returns an Authenticator with the given configuration.
+func NewAuthenticator(config AuthConfig) (*Authenticator, error) {
+	auth := &Authenticator{keys: map[[sha256.Size]byte]*apiKey{}, now: time.Now}
+	if err := auth.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// SetConfig replaces the configuration, keeping the usage counters of keys whose name is unchanged. If the
+// configuration is invalid, the current one is kept and an error is returned.
+func (a *Authenticator) SetConfig(config AuthConfig) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	previous := map[string]*apiKey{}
+	for _, key := range a.keys {
+		previous[key.config.Name] = key
+	}
+
+	keys := map[[sha256.Size]byte]*apiKey{}
+	names := map[string]bool{}
+	for _, key := range config.Keys {
+		if key.Name == "" || key.Key == "" {
+			return fmt.Errorf("API keys need a name and a key")
+		}
+		if names[key.Name] {
+			return fmt.Errorf("duplicate API key name %q", key.Name)
+		}
+		names[key.Name] = true
+		hash := sha256.Sum256([]byte(key.Key))
+		if _, exists := keys[hash]; exists {
+			return fmt.Errorf("API key %q is the same as another key", key.Name)
+		}
+		k := &apiKey{usage: KeyUsage{Name: key.Name, Endpoints: map[string]int64{}}}
+		if old, ok := previous[key.Name]; ok {
+			k.usage, k.day = old.usage, old.day
+		}
+		k.config = key
+		k.usage.Admin, k.usage.DailyQuota = key.Admin, key.DailyQuota
+		keys[hash] = k
+	}
+
+	a.config, a.keys = config, keys
+	return nil
+}
+
+// Enabled reports whether requests are authenticated: if any API keys are configured, or keys are required.
+// Keys required without any configured keys reject every request outside the public routes.
+func (a *Authenticator) Enabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.keys) > 0 || a.config.Required
+}
+
+// Valid reports whether the API key is configured.
+func (a *Authenticator) Valid(key string) bool {
+	_, ok := a.lookup(key)
+	return ok
+}
+
+// lookup returns the configured key with the given secret.
+func (a *Authenticator) lookup(secret string) (*apiKey, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key, ok := a.keys[sha256.Sum256([]byte(secret))]
+	return key, ok
+}
+
+// contextKey is the type of the request context key holding the authenticated API key.
+type contextKey struct{}
+
+// KeyFromContext returns the API key a request was authenticated with, if any.
+func KeyFromContext(ctx context.Context) (APIKeyConfig, bool) {
+	key, ok := ctx.Value(contextKey{}).(APIKeyConfig)
+	return key, ok
+}
+
+// Middleware authenticates requests by their API key (X-API-Key header or "Authorization: Bearer").
+// Requests without a key get 401 Unauthorized if keys are required, and requests with an unknown key always
+// do. Requests for a route the key may not access get 403 Forbidden, and requests over the key's daily quota
+// get 429 Too Many Requests. Accepted requests are counted, and the key is available through KeyFromContext.
+// Responses outside the public routes vary by the key headers, so shared caches keep them apart per key.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.mu.Lock()
+		config, enabled := a.config, len(a.keys) > 0 || a.config.Required
+		a.mu.Unlock()
+		if !enabled || hasPrefix(r.URL.Path, config.PublicRoutes) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Authorization, X-API-Key")
+
+		secret := APIKey(r)
+		if secret == "" {
+			if config.Required {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="countryinfo"`)
+				WriteProblem(w, r, http.StatusUnauthorized, "An API key is required. Send it in the X-API-Key header or as a bearer token.")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := a.lookup(secret)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="countryinfo", error="invalid_token"`)
+			WriteProblem(w, r, http.StatusUnauthorized, "The API key is not valid.")
+			return
+		}
+
+		status, detail, quotaReset := a.admit(key, r.URL.Path)
+		if key.config.DailyQuota > 0 {
+			w.Header().Set("X-Quota-Limit", strconv.Itoa(key.config.DailyQuota))
+			w.Header().Set("X-Quota-Reset", strconv.Itoa(seconds(quotaReset)))
+		}
+		if status != http.StatusOK {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(quotaReset)))
+			}
+			WriteProblem(w, r, status, detail)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, key.config)))
+	})
+}
+
+// admit checks the key's allowed routes and quota for a request and counts it. It returns the status to
+// respond with (200 if the request is accepted), the reason if it is not, and the time until the quota resets.
+func (a *Authenticator) admit(key *apiKey, path string) (int, string, time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if day := now.Format("2006-01-02"); key.day != day {
+		key.day, key.usage.RequestsToday = day, 0
+	}
+
+	if len(key.config.Routes) > 0 && !hasPrefix(path, key.config.Routes) {
+		key.usage.Rejected++
+		return http.StatusForbidden, "The API key may not access this route.", midnight.Sub(now)
+	}
+	if key.config.DailyQuota > 0 && key.usage.RequestsToday >= key.config.DailyQuota {
+		key.usage.Rejected++
+		return http.StatusTooManyRequests, fmt.Sprintf("Daily quota of %d requests used up.", key.config.DailyQuota), midnight.Sub(now)
+	}
+
+	key.usage.Requests++
+	key.usage.RequestsToday++
+	key.usage.LastUsed = &now
+	name := endpoint(path)
+	if _, ok := key.usage.Endpoints[name]; !ok && len(key.usage.Endpoints) >= maxUsageEndpoints {
+		name = otherEndpoints
+	}
+	key.usage.Endpoints[name]++
+	return http.StatusOK, "", midnight.Sub(now)
+}
+
+// Usage returns the usage counters of every API key, ordered by name.
+func (a *Authenticator) Usage() []KeyUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	today := a.now().UTC().Format("2006-01-02")
+	usage := make([]KeyUsage, 0, len(a.keys))
+	for _, key := range a.keys {
+		u := key.usage
+		if key.day != today {
+			u.RequestsToday = 0
+		}
+		u.Endpoints = make(map[string]int64, len(key.usage.Endpoints))
+		for endpoint, count := range key.usage.Endpoints {
+			u.Endpoints[endpoint] = count
+		}
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Name < usage[j].Name })
+	return usage
+}
+
+// hasPrefix reports whether the path starts with any of the prefixes.
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// endpoint returns the first three segments of a path, which name the endpoint without its parameters
+// (e.g., "/countryinfo/v1/info" for "/countryinfo/v1/info/no").
+func endpoint(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return "/" + strings.Join(parts, "/")
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestAuthenticator(t *testing.T, config AuthConfig) (*Authenticator, *time.Time) {
+	t.Helper()
+	auth, err := NewAuthenticator(config)
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	now := time.Date(2024, 1, 2, 23, 59, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+	return auth, &now
+}
+
+func TestAuthenticator(t *testing.T) {
+	auth, now := newTestAuthenticator(t, AuthConfig{
+		Required:     true,
+		PublicRoutes: []string{"/countryinfo/v1/status/"},
+		Keys: []APIKeyConfig{
+			{Name: "dashboard", Key: "s3cret", DailyQuota: 2, Routes: []string{"/countryinfo/v1/info/"}},
+			{Name: "ops", Key: "t0ken", Admin: true},
+		},
+	})
+	var admin bool
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _ := KeyFromContext(r.Context())
+		admin = key.Admin
+	}))
+
+	tests := []struct {
+		path   string
+		header map[string]string
+		want   int
+	}{
+		{"/countryinfo/v1/status/", nil, http.StatusOK},
+		{"/countryinfo/v1/info/no", nil, http.StatusUnauthorized},
+		{"/countryinfo/v1/info/no", map[string]string{"X-API-Key": "wrong"}, http.StatusUnauthorized},
+		{"/countryinfo/v1/info/no", map[string]string{"X-API-Key": "s3cret"}, http.StatusOK},
+		{"/countryinfo/v1/search", map[string]string{"X-API-Key": "s3cret"}, http.StatusForbidden},
+		{"/countryinfo/v1/info/se", map[string]string{"Authorization": "Bearer s3cret"}, http.StatusOK},
+		{"/countryinfo/v1/info/dk", map[string]string{"X-API-Key": "s3cret"}, http.StatusTooManyRequests},
+		{"/countryinfo/v1/search", map[string]string{"X-API-Key": "t0ken"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := request(handler, tt.path, "192.0.2.1:1", tt.header)
+		if w.Code != tt.want {
+			t.Errorf("%s %v: got %d, want %d", tt.path, tt.header, w.Code, tt.want)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %v: 401 without WWW-Authenticate", tt.path, tt.header)
+		}
+		if public := tt.path == "/countryinfo/v1/status/"; public != (w.Header().Get("Vary") == "") {
+			t.Errorf("%s: Vary = %q, want the key headers outside the public routes", tt.path, w.Header().Get("Vary"))
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("Retry-After = %q, want the 60 seconds to midnight", w.Header().Get("Retry-After"))
+		}
+	}
+	if !admin {
+		t.Error("expected the admin key in the request context")
+	}
+
+	usage := auth.Usage()
+	if len(usage) != 2 || usage[0].Name != "dashboard" {
+		t.Fatalf("Usage() = %+v", usage)
+	}
+	if u := usage[0]; u.Requests != 2 || u.RequestsToday != 2 || u.Rejected != 2 || u.Endpoints["/countryinfo/v1/info"] != 2 {
+		t.Errorf("dashboard usage = %+v", u)
+	}
+
+	// The quota resets at midnight UTC
+	*now = now.Add(time.Minute)
+	if w := request(handler, "/countryinfo/v1/info/dk", "192.0.2.1:1", map[string]string{"X-API-Key": "s3cret"}); w.Code != http.StatusOK {
+		t.Errorf("after midnight got %d, want 200", w.Code)
+	}
+}
+
+func TestAuthenticatorOptional(t *testing.T) {
+	auth, _ := newTestAuthenticator(t, AuthConfig{Keys: []APIKeyConfig{{Name: "etl", Key: "k"}}})
+	handler := auth.Middleware(okHandler)
+
+	if w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1", nil); w.Code != http.StatusOK {
+		t.Errorf("without key got %d, want 200 when keys are optional", w.Code)
+	}
+	if w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1", map[string]string{"X-API-Key": "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("invalid key got %d, want 401", w.Code)
+	}
+	if !auth.Valid("k") || auth.Valid("wrong") {
+		t.Error("Valid does not match the configured keys")
+	}
+}
+
+func TestAuthenticatorRequiredWithoutKeys(t *testing.T) {
+	auth, _ := newTestAuthenticator(t, AuthConfig{Required: true, PublicRoutes: []string{"/countryinfo/v1/status/"}})
+	handler := auth.Middleware(okHandler)
+
+	if w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without keys configured got %d, want 401 when keys are required", w.Code)
+	}
+	if w := request(handler, "/countryinfo/v1/status/", "192.0.2.1:1", nil); w.Code != http.StatusOK {
+		t.Errorf("public route got %d, want 200", w.Code)
+	}
+}
+
+func TestAuthenticatorSetConfig(t *testing.T) {
+	auth, _ := newTestAuthenticator(t, AuthConfig{Keys: []APIKeyConfig{{Name: "etl", Key: "old"}, {Name: "gone", Key: "g"}}})
+	handler := auth.Middleware(okHandler)
+	request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1", map[string]string{"X-API-Key": "old"})
+
+	// A rotated key keeps its usage, and removed keys are no longer valid
+	if err := auth.SetConfig(AuthConfig{Keys: []APIKeyConfig{{Name: "etl", Key: "new", Admin: true}}}); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+	if auth.Valid("old") || auth.Valid("g") || !auth.Valid("new") {
+		t.Error("Valid does not match the new keys")
+	}
+	request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1", map[string]string{"X-API-Key": "new"})
+	if usage := auth.Usage(); len(usage) != 1 || usage[0].Requests != 2 || !usage[0].Admin {
+		t.Errorf("Usage() = %+v, want etl with 2 requests", usage)
+	}
+
+	// An invalid configuration keeps the current one
+	if err := auth.SetConfig(AuthConfig{Keys: []APIKeyConfig{{Name: "etl"}}}); err == nil {
+		t.Error("expected error for a key without a secret")
+	}
+	if !auth.Valid("new") {
+		t.Error("invalid configuration replaced the current one")
+	}
+}
+
+func TestAuthenticatorUsageEndpoints(t *testing.T) {
+	auth, _ := newTestAuthenticator(t, AuthConfig{Keys: []APIKeyConfig{{Name: "etl", Key: "k"}}})
+	handler := auth.Middleware(okHandler)
+
+	// Made-up paths are counted separately only up to the limit, and the rest under "other"
+	for i := 0; i < maxUsageEndpoints+10; i++ {
+		request(handler, fmt.Sprintf("/countryinfo/v1/made-up-%d", i), "192.0.2.1:1", map[string]string{"X-API-Key": "k"})
+	}
+	request(handler, "/countryinfo/v1/made-up-0/again", "192.0.2.1:1", map[string]string{"X-API-Key": "k"})
+	endpoints := auth.Usage()[0].Endpoints
+	if len(endpoints) != maxUsageEndpoints+1 || endpoints[otherEndpoints] != 10 || endpoints["/countryinfo/v1/made-up-0"] != 2 {
+		t.Errorf("%d endpoints counted, %d under other, want %d and 10", len(endpoints), endpoints[otherEndpoints], maxUsageEndpoints+1)
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("dashboard=s3cret, etl=a=b")
+	if err != nil || len(keys) != 2 || keys[1].Name != "etl" || keys[1].Key != "a=b" {
+		t.Errorf("ParseAPIKeys = %+v, %v", keys, err)
+	}
+	_, err = ParseAPIKeys("dashboard=s3cret, t0ken")
+	if err == nil || err.Error() != "entry 2 is not name=key" {
+		t.Errorf("got error %v, want entry 2 reported for a pair without a name", err)
+	}
+	if _, err := NewAuthenticator(AuthConfig{Keys: []APIKeyConfig{{Name: "a", Key: "x"}, {Name: "b", Key: "x"}}}); err == nil {
+		t.Error("expected error for duplicate keys")
+	}
+}
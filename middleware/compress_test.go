@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br":       "br",
+		"gzip, deflate, br, zstd": "br",
+		"zstd, gzip;q=0.8":        "zstd",
+		"br;q=0.5, gzip":          "gzip",
+		"*":                       "br",
+		"*, br;q=0":               "zstd",
+		"identity":                "",
+		"gzip;q=0, deflate":       "",
+		"GZIP":                    "gzip",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func serve(handler http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"name":"Oslo","population":709037},`, 100)
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"abc"`)
+		// Write in pieces to exercise buffering up to the minimum size
+		for i := 0; i < len(body); i += 100 {
+			io.WriteString(w, body[i:min(i+100, len(body))])
+		}
+	}), 1024)
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for encoding, decode := range decoders {
+		// Run twice to reuse pooled encoders
+		for i := 0; i < 2; i++ {
+			w := serve(handler, encoding)
+			if got := w.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, encoding)
+			}
+			if got := w.Header().Get("ETag"); got != `W/"abc"` {
+				t.Errorf("ETag = %q, want weak ETag", got)
+			}
+			if w.Body.Len() >= len(body) {
+				t.Errorf("%s: compressed size %d not smaller than %d", encoding, w.Body.Len(), len(body))
+			}
+			reader, err := decode(bytes.NewReader(w.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("%s: %v", encoding, err)
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil || string(decoded) != body {
+				t.Errorf("%s: decoded body differs (err %v)", encoding, err)
+			}
+		}
+	}
+}
+
+func TestCompressSkipsSmallAndUnacceptable(t *testing.T) {
+	handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "country not found")
+	}), 1024)
+
+	for _, acceptEncoding := range []string{"gzip", ""} {
+		w := serve(handler, acceptEncoding)
+		if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "country not found" {
+			t.Errorf("Accept-Encoding %q: small response should be sent as it is, got %q", acceptEncoding, w.Body.String())
+		}
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Vary = %q, want Accept-Encoding", w.Header().Get("Vary"))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Deprecation announces the deprecation of an API version to its clients.
+type Deprecation struct {
+	At     time.Time // When the version was or will be deprecated; zero if it is not deprecated
+	Sunset time.Time // When the version will stop working; zero if not decided
+	Link   string    // URL of documentation about the deprecation, such as a migration guide
+}
+
+// Deprecate adds headers announcing the deprecation to every response of next: Deprecation (RFC 9745) with the
+// deprecation date, Sunset (RFC 8594) with the sunset date, and a Link to the documentation. Headers for the
+// parts that are not set, or all of them while no deprecation is stored, are left out. The deprecation is loaded on
+// every request, so storing another one applies at once.
+func Deprecate(next http.Handler, deprecation *atomic.Pointer[Deprecation]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d Deprecation
+		if stored := deprecation.Load(); stored != nil {
+			d = *stored
+		}
+		header := w.Header()
+		if !d.At.IsZero() {
+			header.Set("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+		}
+		if !d.Sunset.IsZero() {
+			header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Link != "" && (!d.At.IsZero() || !d.Sunset.IsZero()) {
+			header.Add("Link", "<"+d.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeprecate(t *testing.T) {
+	var deprecation atomic.Pointer[Deprecation]
+	handler := Deprecate(okHandler, &deprecation)
+
+	w := request(handler, "/countryinfo/v1/status", "192.0.2.1:1", nil)
+	for _, name := range []string{"Deprecation", "Sunset", "Link"} {
+		if got := w.Header().Get(name); got != "" {
+			t.Errorf("%s = %q before the version is deprecated", name, got)
+		}
+	}
+
+	// Changes apply to the next request
+	deprecation.Store(&Deprecation{
+		At:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Link:   "https://example.com/migrate-to-v2",
+	})
+	w = request(handler, "/countryinfo/v1/status", "192.0.2.1:1", nil)
+	want := map[string]string{
+		"Deprecation": "@1704067200",
+		"Sunset":      "Mon, 01 Jul 2024 00:00:00 GMT",
+		"Link":        `<https://example.com/migrate-to-v2>; rel="deprecation"; type="text/html"`,
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
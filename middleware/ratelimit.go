@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: clients may send Burst requests at once, and get RequestsPerMinute more
+// each minute. A RequestsPerMinute of 0 disables limiting.
+type RateLimit struct {
+	RequestsPerMinute float64 `json:"requestsPerMinute"`
+	Burst             int     `json:"burst"`
+}
+
+// RouteRateLimit is the rate limit of the routes whose path starts with Prefix.
+type RouteRateLimit struct {
+	Prefix string `json:"prefix"`
+	RateLimit
+}
+
+// RateLimitConfig configures a RateLimiter.
+type RateLimitConfig struct {
+	// TrustedProxies are the addresses or CIDR ranges of proxies whose X-Forwarded-For header is honored.
+	TrustedProxies []string `json:"trustedProxies"`
+	// Default applies to routes without a limit of their own.
+	Default RateLimit `json:"default"`
+	// Routes are limits for specific routes. The longest matching prefix is used.
+	Routes []RouteRateLimit `json:"routes"`
+}
+
+// DefaultRateLimitConfig does not limit any request. Rate limiting is opt-in: clients without an API key are
+// identified by IP address, and behind a reverse proxy that is not listed in TrustedProxies every client
+// would share the proxy's limit.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{}
+}
+
+// LoadRateLimitConfig reads a RateLimitConfig from a JSON file.
+func LoadRateLimitConfig(path string) (RateLimitConfig, error) {
+	var config RateLimitConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read rate limit file: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse rate limit file: %w", err)
+	}
+	return config, nil
+}
+
+// bucket holds the tokens of one client for one rate limit.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	refill time.Duration // Time for an empty bucket to fill up
+}
+
+// RateLimiter limits the request rate of each client per route. Clients are identified by their API key,
+// or otherwise by their IP address.
+type RateLimiter struct {
+	// ValidAPIKey reports whether an API key is known. Only known keys identify a client, so that made-up
+	// keys cannot be used to get a fresh bucket; until it is set, all clients are identified by IP address.
+	ValidAPIKey func(key string) bool
+
+	mu        sync.Mutex
+	config    RateLimitConfig
+	trusted   []*net.IPNet
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter with the given configuration.
+func NewRateLimiter(config RateLimitConfig) (*RateLimiter, error) {
+	limiter := &RateLimiter{buckets: map[string]*bucket{}, now: time.Now}
+	if err := limiter.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// SetConfig replaces the configuration. Clients keep their buckets, which fill up to the new limits. If the
+// configuration is invalid, the current one is kept and an error is returned.
+func (l *RateLimiter) SetConfig(config RateLimitConfig) error {
+	trusted := []*net.IPNet{}
+	for _, proxy := range config.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config, l.trusted = config, trusted
+	return nil
+}
+
+// Middleware rejects requests over the rate limit of their route with 429 Too Many Requests and a
+// Retry-After header. Responses on limited routes have RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, and a RateLimit-Policy header describing the limit.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix, limit := l.routeLimit(r.URL.Path)
+		if limit.RequestsPerMinute <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = int(math.Max(1, math.Ceil(limit.RequestsPerMinute)))
+		}
+		allowed, remaining, reset, retryAfter := l.take(prefix+" "+l.clientKey(r), limit.RequestsPerMinute/60, burst)
+
+		header := w.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", burst, int(math.Ceil(float64(burst)/limit.RequestsPerMinute*60))))
+		header.Set("RateLimit-Limit", strconv.Itoa(burst))
+		header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+		if !allowed {
+			header.Set("Retry-After", strconv.Itoa(seconds(retryAfter)))
+			WriteProblem(w, r, http.StatusTooManyRequests,
+				fmt.Sprintf("Rate limit of %g requests per minute exceeded. Retry in %d seconds.", limit.RequestsPerMinute, seconds(retryAfter)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeLimit returns the prefix and rate limit of the route matching the path.
+func (l *RateLimiter) routeLimit(path string) (string, RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prefix, limit := "", l.config.Default
+	for _, route := range l.config.Routes {
+		if strings.HasPrefix(path, route.Prefix) && len(route.Prefix) > len(prefix) {
+			prefix, limit = route.Prefix, route.RateLimit
+		}
+	}
+	return prefix, limit
+}
+
+// clientKey identifies the client of a request by its API key if it is known, otherwise by its IP address.
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if key := APIKey(r); key != "" && l.ValidAPIKey != nil && l.ValidAPIKey(key) {
+		return "key:" + key
+	}
+	return "ip:" + l.ClientIP(r)
+}
+
+// ClientIP returns the IP address of the client. If the request comes from a trusted proxy, the
+// X-Forwarded-For header is followed from the right, skipping trusted proxies, to the first address
+// not added by one of them.
+func (l *RateLimiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.isTrusted(host) {
+		return host
+	}
+
+	forwarded := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(address) == nil {
+			break
+		}
+		host = address
+		if !l.isTrusted(address) {
+			break
+		}
+	}
+	return host
+}
+
+// isTrusted reports whether the address belongs to a trusted proxy. The caller holds l.mu.
+func (l *RateLimiter) isTrusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range l.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// take removes a token from the client's bucket if it has one. It returns whether the request is allowed,
+// the whole tokens left, the time until the bucket is full again and the time until the next token.
+func (l *RateLimiter) take(key string, perSecond float64, burst int) (bool, int, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now, refill: time.Duration(float64(burst) / perSecond * float64(time.Second))}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	reset := time.Duration((float64(burst) - b.tokens) / perSecond * float64(time.Second))
+	retryAfter := time.Duration(0)
+	if !allowed {
+		retryAfter = time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	}
+	return allowed, int(b.tokens), reset, retryAfter
+}
+
+// sweep removes the buckets of clients that have been idle long enough for a bucket to refill, at most
+// once a minute, so that the map does not grow with every client ever seen.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > b.refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// seconds rounds a duration up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// APIKey returns the API key of a request, from the X-API-Key header or an "Authorization: Bearer" header.
+func APIKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
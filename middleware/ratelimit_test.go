@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(t *testing.T, config RateLimitConfig) (*RateLimiter, *time.Time) {
+	t.Helper()
+	limiter, err := NewRateLimiter(config)
+	if err != nil {
+		t.Fatalf("NewRateLimiter: %v", err)
+	}
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func request(handler http.Handler, path, remoteAddr string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = remoteAddr
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestRateLimiterBucket(t *testing.T) {
+	limiter, now := newTestLimiter(t, RateLimitConfig{Default: RateLimit{RequestsPerMinute: 60, Burst: 2}})
+	handler := limiter.Middleware(okHandler)
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1234", nil)
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: got %d with %s remaining", i, w.Code, w.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1234", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	var problem Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil || problem.Status != http.StatusTooManyRequests {
+		t.Errorf("expected a problem response, got %v (err %v)", problem, err)
+	}
+	if w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Content-Type = %q", w.Header().Get("Content-Type"))
+	}
+
+	// Another client has its own bucket
+	if w := request(handler, "/countryinfo/v1/info/no", "192.0.2.2:1234", nil); w.Code != http.StatusOK {
+		t.Errorf("other client got %d, want 200", w.Code)
+	}
+
+	// A token is added every second
+	*now = now.Add(time.Second)
+	if w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1234", nil); w.Code != http.StatusOK {
+		t.Errorf("after a second got %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimiterDefaultConfig(t *testing.T) {
+	limiter, _ := newTestLimiter(t, DefaultRateLimitConfig())
+	handler := limiter.Middleware(okHandler)
+
+	// Without a configuration, nothing is limited
+	for i := 0; i < 100; i++ {
+		w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1234", nil)
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request %d: got %d with limit %q, want no limit", i, w.Code, w.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
+
+func TestRateLimiterRoutes(t *testing.T) {
+	limiter, _ := newTestLimiter(t, RateLimitConfig{
+		Default: RateLimit{RequestsPerMinute: 60, Burst: 1},
+		Routes: []RouteRateLimit{
+			{Prefix: "/countryinfo/v1/status/"},
+			{Prefix: "/countryinfo/v1/info/", RateLimit: RateLimit{RequestsPerMinute: 60, Burst: 3}},
+		},
+	})
+	handler := limiter.Middleware(okHandler)
+
+	for i := 0; i < 5; i++ {
+		if w := request(handler, "/countryinfo/v1/status/", "192.0.2.1:1", nil); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("unlimited route: got %d with limit %q", w.Code, w.Header().Get("RateLimit-Limit"))
+		}
+	}
+	if w := request(handler, "/countryinfo/v1/info/no", "192.0.2.1:1", nil); w.Header().Get("RateLimit-Limit") != "3" {
+		t.Errorf("RateLimit-Limit = %q, want 3", w.Header().Get("RateLimit-Limit"))
+	}
+	// Routes have separate buckets
+	if w := request(handler, "/countryinfo/v1/search", "192.0.2.1:1", nil); w.Code != http.StatusOK {
+		t.Errorf("default route got %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimiterSetConfig(t *testing.T) {
+	limiter, _ := newTestLimiter(t, RateLimitConfig{Default: RateLimit{RequestsPerMinute: 60, Burst: 1}})
+	handler := limiter.Middleware(okHandler)
+	request(handler, "/", "192.0.2.1:1", nil)
+
+	if err := limiter.SetConfig(RateLimitConfig{TrustedProxies: []string{"not-an-ip"}}); err == nil {
+		t.Error("expected error for an invalid trusted proxy")
+	}
+	if w := request(handler, "/", "192.0.2.1:1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("after an invalid configuration got %d, want the current limit's 429", w.Code)
+	}
+
+	if err := limiter.SetConfig(RateLimitConfig{}); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+	if w := request(handler, "/", "192.0.2.1:1", nil); w.Code != http.StatusOK {
+		t.Errorf("after removing the limit got %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimiterAPIKeys(t *testing.T) {
+	limiter, _ := newTestLimiter(t, RateLimitConfig{Default: RateLimit{RequestsPerMinute: 60, Burst: 1}})
+	handler := limiter.Middleware(okHandler)
+
+	// Unknown keys do not give a fresh bucket
+	request(handler, "/", "192.0.2.1:1", nil)
+	if w := request(handler, "/", "192.0.2.1:1", map[string]string{"X-API-Key": "made-up"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("unknown key got %d, want 429", w.Code)
+	}
+
+	limiter.ValidAPIKey = func(key string) bool { return key == "known" }
+	for _, header := range []map[string]string{{"X-API-Key": "known"}, {"Authorization": "Bearer known"}} {
+		w := request(handler, "/", "192.0.2.1:1", header)
+		if header["X-API-Key"] != "" && w.Code != http.StatusOK {
+			t.Errorf("known key got %d, want 200", w.Code)
+		}
+		if header["Authorization"] != "" && w.Code != http.StatusTooManyRequests {
+			t.Errorf("bearer token should share the key's bucket, got %d", w.Code)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	limiter, _ := newTestLimiter(t, RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.10"}})
+
+	tests := []struct {
+		remoteAddr, forwardedFor, want string
+	}{
+		{"198.51.100.7:5000", "", "198.51.100.7"},
+		{"198.51.100.7:5000", "203.0.113.9", "198.51.100.7"}, // Untrusted peer: header ignored
+		{"10.1.2.3:5000", "203.0.113.9", "203.0.113.9"},
+		{"10.1.2.3:5000", "1.1.1.1, 203.0.113.9, 192.0.2.10", "203.0.113.9"}, // Spoofed left part ignored
+		{"10.1.2.3:5000", "10.4.4.4", "10.4.4.4"},
+		{"10.1.2.3:5000", "garbage", "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+		}
+		if got := limiter.ClientIP(r); got != tt.want {
+			t.Errorf("ClientIP(%s, %q) = %s, want %s", tt.remoteAddr, tt.forwardedFor, got, tt.want)
+		}
+	}
+
+	if _, err := NewRateLimiter(RateLimitConfig{TrustedProxies: []string{"not an address"}}); err == nil {
+		t.Error("expected error for invalid trusted proxy")
+	}
+}
@@ -0,0 +1,25 @@
+// Package openapi holds the OpenAPI 3.1 description of the service's endpoints and the Swagger UI page to browse it.
+package openapi
+
+import (
+	"embed"
+)
+
+// Spec is the OpenAPI 3.1 document describing every endpoint of the API versions, as JSON. Its paths are relative
+// to the server URL /countryinfo/{version}. It is maintained by hand: change it along with the handlers, and the
+// handler tests check that their responses still validate against it.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// SwaggerUI is an HTML page showing the document at /openapi.json in Swagger UI, with the script and styles
+// in SwaggerUIAssets served under /docs/.
+//
+//go:embed swagger-ui.html
+var SwaggerUI []byte
+
+// SwaggerUIAssets holds the Swagger UI script and styles, vendored from swagger-ui-dist so the page needs
+// neither a CDN nor internet access. See swagger-ui/README.md for the version.
+//
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css
+var SwaggerUIAssets embed.FS
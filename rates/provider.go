@@ -0,0 +1,95 @@
+// Package rates provides currency exchange rates through a pluggable provider.
+package rates
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed data/rates.json
+var defaultRates []byte
+
+// Provider supplies exchange rates between currencies, identified by ISO 4217 codes.
+type Provider interface {
+	// Rate returns how many units of the to currency one unit of the from currency is worth.
+	Rate(from, to string) (float64, error)
+	// Source describes where the rates come from and how current they are.
+	Source() string
+}
+
+// FileProvider serves fixed rates read from a JSON file, all relative to one base currency.
+type FileProvider struct {
+	Base        string             `json:"base"`
+	Date        string             `json:"date"`
+	Description string             `json:"source"`
+	Rates       map[string]float64 `json:"rates"` // Units of each currency per unit of the base currency
+}
+
+// Default returns a FileProvider with the stand-in rates bundled with the service.
+func Default() *FileProvider {
+	provider, err := parse(defaultRates)
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled exchange rates: %v", err))
+	}
+	return provider
+}
+
+// LoadFile reads a FileProvider from a JSON file in the same format as the bundled rates:
+// {"base": "USD", "date": "2024-01-02", "source": "...", "rates": {"EUR": 0.91, ...}}.
+func LoadFile(path string) (*FileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates: %w", err)
+	}
+	return parse(data)
+}
+
+// parse decodes and validates exchange rates.
+func parse(data []byte) (*FileProvider, error) {
+	var provider FileProvider
+	if err := json.Unmarshal(data, &provider); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange rates: %w", err)
+	}
+	if provider.Base == "" || len(provider.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rates must have a base currency and rates")
+	}
+
+	// Normalize codes and make sure the base converts to itself
+	rates := make(map[string]float64, len(provider.Rates)+1)
+	for code, rate := range provider.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid rate for %s: %v", code, rate)
+		}
+		rates[strings.ToUpper(code)] = rate
+	}
+	provider.Base = strings.ToUpper(provider.Base)
+	rates[provider.Base] = 1
+	provider.Rates = rates
+
+	return &provider, nil
+}
+
+// Rate returns how many units of the to currency one unit of the from currency is worth,
+// converting through the base currency.
+func (p *FileProvider) Rate(from, to string) (float64, error) {
+	fromRate, ok := p.Rates[strings.ToUpper(from)]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for currency: %s", from)
+	}
+	toRate, ok := p.Rates[strings.ToUpper(to)]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for currency: %s", to)
+	}
+	return toRate / fromRate, nil
+}
+
+// Source describes the rates and the date they are from.
+func (p *FileProvider) Source() string {
+	if p.Date == "" {
+		return p.Description
+	}
+	return fmt.Sprintf("%s (%s)", p.Description, p.Date)
+}
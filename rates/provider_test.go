@@ -0,0 +1,59 @@
+package rates
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRates(t *testing.T) {
+	provider := Default()
+
+	rate, err := provider.Rate("USD", "NOK")
+	if err != nil {
+		t.Fatalf("Rate(USD, NOK) returned error: %v", err)
+	}
+	if rate != provider.Rates["NOK"] {
+		t.Errorf("Rate(USD, NOK) = %v, want %v", rate, provider.Rates["NOK"])
+	}
+
+	// Converting through the base currency must be consistent in both directions
+	there, _ := provider.Rate("eur", "sek")
+	back, _ := provider.Rate("SEK", "EUR")
+	if math.Abs(there*back-1) > 1e-9 {
+		t.Errorf("EUR->SEK (%v) and SEK->EUR (%v) are not inverse", there, back)
+	}
+
+	if _, err := provider.Rate("USD", "XXX"); err == nil {
+		t.Error("expected error for unknown currency")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(`{"base":"eur","date":"2024-06-01","source":"Test","rates":{"NOK":11.5}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile returned error: %v", err)
+	}
+	if rate, _ := provider.Rate("NOK", "EUR"); math.Abs(rate-1/11.5) > 1e-9 {
+		t.Errorf("Rate(NOK, EUR) = %v, want %v", rate, 1/11.5)
+	}
+	if got := provider.Source(); !strings.Contains(got, "2024-06-01") {
+		t.Errorf("Source() = %q, want it to include the date", got)
+	}
+}
+
+func TestLoadFileRejectsInvalidRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	os.WriteFile(path, []byte(`{"base":"USD","rates":{"NOK":-1}}`), 0644)
+
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected error for negative rate")
+	}
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// encodeCSV writes v as CSV with a header row. For responses holding a list, each item of the list is a row
+// (e.g., a year,value row per population count). Other responses are a single row. Nested objects are
+// flattened into dotted column names (e.g., "currencies.NOK.name"), lists of values are joined with ";" and
+// lists of objects are numbered (e.g., "cities.0.name").
+func encodeCSV(w io.Writer, v interface{}, list string) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+
+	items := []interface{}{tree}
+	if values, ok := tree.([]interface{}); ok {
+		items = values
+	} else if obj, ok := tree.(object); ok && list != "" {
+		if value, ok := obj.get(list); ok {
+			values, _ := value.([]interface{})
+			items = values
+		}
+	}
+
+	// Flatten each row, collecting the columns in the order they are first seen
+	columns := []string{}
+	seen := map[string]bool{}
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		row := map[string]string{}
+		flatten("", item, row, func(column string) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		})
+		rows = append(rows, row)
+	}
+
+	writer := csv.NewWriter(w)
+	if len(columns) > 0 {
+		writer.Write(columns)
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// flatten adds the value to the row under the column name prefix, recursing into objects and lists of objects.
+// addColumn is called for each column in order.
+func flatten(prefix string, value interface{}, row map[string]string, addColumn func(string)) {
+	set := func(text string) {
+		column := prefix
+		if column == "" {
+			column = "value"
+		}
+		addColumn(column)
+		row[column] = text
+	}
+
+	switch v := value.(type) {
+	case object:
+		for _, m := range v {
+			flatten(joinColumn(prefix, m.Key), m.Value, row, addColumn)
+		}
+	case []interface{}:
+		texts := make([]string, 0, len(v))
+		for _, item := range v {
+			if !isScalar(item) {
+				// A list of objects or lists: number the items
+				for i, item := range v {
+					flatten(joinColumn(prefix, strconv.Itoa(i)), item, row, addColumn)
+				}
+				return
+			}
+			texts = append(texts, scalarText(item))
+		}
+		set(strings.Join(texts, ";"))
+	default:
+		set(scalarText(v))
+	}
+}
+
+// joinColumn appends a key to a dotted column name.
+func joinColumn(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,35 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// encodeNDJSON writes the items of the list in v as newline-delimited JSON, one item per line.
+// v may also be a list itself.
+func encodeNDJSON(w io.Writer, v interface{}, list string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if list != "" {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("response is not an object: %w", err)
+		}
+		data = fields[list]
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("response has no list: %w", err)
+	}
+
+	for _, item := range items {
+		if _, err := w.Write(append(item, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package render encodes responses in the format a client asks for, chosen from the "format" query parameter
+// or the Accept header: JSON, CSV, XML, YAML or, for lists, NDJSON.
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned by Negotiate when no format satisfies the Accept header.
+var ErrNotAcceptable = errors.New("no acceptable format")
+
+// Format is an output format.
+type Format struct {
+	Name        string   // Value of the "format" query parameter selecting it
+	ContentType string   // Content-Type of the encoded responses
+	MediaTypes  []string // Media types selecting it in the Accept header
+	ListOnly    bool     // Only available for responses holding a list
+
+	// Encode writes v to w. list names the member of v holding its list of items, or is empty if there is none.
+	Encode func(w io.Writer, v interface{}, list string) error
+}
+
+// Registry holds the supported formats. The first registered format is the default.
+type Registry struct {
+	formats []*Format
+}
+
+// NewRegistry returns a registry with the given formats.
+func NewRegistry(formats ...*Format) *Registry {
+	registry := &Registry{}
+	for _, format := range formats {
+		registry.Register(format)
+	}
+	return registry
+}
+
+// Register adds a format, replacing any format with the same name.
+func (r *Registry) Register(format *Format) {
+	for i, existing := range r.formats {
+		if existing.Name == format.Name {
+			r.formats[i] = format
+			return
+		}
+	}
+	r.formats = append(r.formats, format)
+}
+
+// Names returns the names of the formats available for list or other responses.
+func (r *Registry) Names(list bool) []string {
+	names := []string{}
+	for _, format := range r.formats {
+		if list || !format.ListOnly {
+			names = append(names, format.Name)
+		}
+	}
+	return names
+}
+
+// Negotiate chooses the format for a response. A "format" query parameter takes precedence over the Accept
+// header; an unknown format is an error. Otherwise the acceptable media type with the highest quality is used,
+// the default format if the header is empty, and ErrNotAcceptable if no format matches it. If none of the
+// client's most preferred media ranges matches a format, the default format is used whenever it is acceptable
+// at all: browsers prefer HTML and rank XML above */*, but should get the default format rather than XML.
+func (r *Registry) Negotiate(formatParam, accept string, list bool) (*Format, error) {
+	if formatParam != "" {
+		for _, format := range r.formats {
+			if strings.EqualFold(format.Name, formatParam) && (list || !format.ListOnly) {
+				return format, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported format %q, use one of: %s", formatParam, strings.Join(r.Names(list), ", "))
+	}
+	if strings.TrimSpace(accept) == "" {
+		return r.formats[0], nil
+	}
+
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return nil, ErrNotAcceptable
+	}
+	for _, mediaRange := range ranges {
+		format := r.match(mediaRange.name, list)
+		if format == nil {
+			continue
+		}
+		if mediaRange.quality < ranges[0].quality {
+			// The most preferred ranges match no format
+			for _, other := range ranges {
+				if r.formats[0].matches(other.name) {
+					return r.formats[0], nil
+				}
+			}
+		}
+		return format, nil
+	}
+	return nil, ErrNotAcceptable
+}
+
+// match returns the first format included in a media range, or nil if there is none.
+func (r *Registry) match(mediaRange string, list bool) *Format {
+	for _, format := range r.formats {
+		if format.ListOnly && !list {
+			continue
+		}
+		if format.matches(mediaRange) {
+			return format
+		}
+	}
+	return nil
+}
+
+// matches reports whether a media range includes one of the format's media types.
+func (f *Format) matches(mediaRange string) bool {
+	for _, mediaType := range f.MediaTypes {
+		if matchMediaType(mediaRange, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedRange is a media range of an Accept header, with its quality.
+type acceptedRange struct {
+	name    string
+	quality float64
+}
+
+// parseAccept returns the media ranges of an Accept header with a non-zero quality, highest quality first.
+func parseAccept(accept string) []acceptedRange {
+	ranges := []acceptedRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, acceptedRange{mediaRange, quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+	return ranges
+}
+
+// matchMediaType reports whether a media range such as "text/*" includes the media type.
+func matchMediaType(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
+
+// Formats.
+var (
+	JSON = &Format{
+		Name:        "json",
+		ContentType: "application/json",
+		MediaTypes:  []string{"application/json"},
+		Encode: func(w io.Writer, v interface{}, list string) error {
+			return json.NewEncoder(w).Encode(v)
+		},
+	}
+	CSV = &Format{
+		Name:        "csv",
+		ContentType: "text/csv; charset=utf-8",
+		MediaTypes:  []string{"text/csv"},
+		Encode:      encodeCSV,
+	}
+	XML = &Format{
+		Name:        "xml",
+		ContentType: "application/xml; charset=utf-8",
+		MediaTypes:  []string{"application/xml", "text/xml"},
+		Encode:      encodeXML,
+	}
+	YAML = &Format{
+		Name:        "yaml",
+		ContentType: "application/yaml; charset=utf-8",
+		MediaTypes:  []string{"application/yaml", "application/x-yaml", "text/yaml"},
+		Encode:      encodeYAML,
+	}
+	NDJSON = &Format{
+		Name:        "ndjson",
+		ContentType: "application/x-ndjson",
+		MediaTypes:  []string{"application/x-ndjson", "application/ndjson"},
+		ListOnly:    true,
+		Encode:      encodeNDJSON,
+	}
+)
+
+// Default is the registry of all formats, with JSON as the default.
+var Default = NewRegistry(JSON, CSV, XML, YAML, NDJSON)
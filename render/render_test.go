@@ -0,0 +1,151 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type population struct {
+	Mean   int `json:"mean"`
+	Values []struct {
+		Year  int `json:"year"`
+		Value int `json:"value"`
+	} `json:"values"`
+}
+
+func samplePopulation() population {
+	var p population
+	json.Unmarshal([]byte(`{"mean":150,"values":[{"year":2019,"value":100},{"year":2020,"value":200}]}`), &p)
+	return p
+}
+
+type country struct {
+	Name       string                       `json:"name"`
+	Population int                          `json:"population"`
+	Borders    []string                     `json:"borders"`
+	Currencies map[string]map[string]string `json:"currencies"`
+	Cities     []map[string]string          `json:"cities"`
+	Capital    *string                      `json:"capital"`
+}
+
+func sampleCountry() country {
+	return country{
+		Name:       "Norway",
+		Population: 5379475,
+		Borders:    []string{"FIN", "SWE", "RUS"},
+		Currencies: map[string]map[string]string{"NOK": {"name": "Norwegian krone"}},
+		Cities:     []map[string]string{{"name": "Oslo"}, {"name": "Bergen, Vestland"}},
+	}
+}
+
+func encode(t *testing.T, format *Format, v interface{}, list string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := format.Encode(&buf, v, list); err != nil {
+		t.Fatalf("%s encode: %v", format.Name, err)
+	}
+	return buf.String()
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		format, accept string
+		list           bool
+		want           string
+	}{
+		{"", "", false, "json"},
+		{"csv", "application/json", false, "csv"}, // The parameter takes precedence
+		{"YAML", "", false, "yaml"},
+		{"", "text/csv", true, "csv"},
+		{"", "application/xml;q=0.5, text/yaml", false, "yaml"},
+		{"", "text/html, application/xhtml+xml, */*;q=0.8", false, "json"},
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false, "json"}, // A browser
+		{"", "text/html, application/xml;q=0.9", false, "xml"},                                 // JSON is not acceptable
+		{"", "application/xml;q=0.9, */*;q=0.8", false, "xml"},
+		{"", "application/xml, application/json;q=0.5", false, "xml"},
+		{"", "text/*", false, "csv"},
+		{"", "application/x-ndjson", true, "ndjson"},
+		{"", "application/x-ndjson, application/json;q=0.5", false, "json"}, // NDJSON is only for lists
+	}
+	for _, tt := range tests {
+		format, err := Default.Negotiate(tt.format, tt.accept, tt.list)
+		if err != nil {
+			t.Errorf("Negotiate(%q, %q, %v): %v", tt.format, tt.accept, tt.list, err)
+			continue
+		}
+		if format.Name != tt.want {
+			t.Errorf("Negotiate(%q, %q, %v) = %s, want %s", tt.format, tt.accept, tt.list, format.Name, tt.want)
+		}
+	}
+
+	if _, err := Default.Negotiate("pdf", "", false); err == nil || errors.Is(err, ErrNotAcceptable) {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+	if _, err := Default.Negotiate("ndjson", "", false); err == nil {
+		t.Error("expected error for ndjson on a response without a list")
+	}
+	if _, err := Default.Negotiate("", "image/png", false); !errors.Is(err, ErrNotAcceptable) {
+		t.Errorf("expected ErrNotAcceptable, got %v", err)
+	}
+}
+
+func TestCSVList(t *testing.T) {
+	got := encode(t, CSV, samplePopulation(), "values")
+	want := "year,value\n2019,100\n2020,200\n"
+	if got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+}
+
+func TestCSVFlattened(t *testing.T) {
+	got := encode(t, CSV, sampleCountry(), "")
+	want := "name,population,borders,currencies.NOK.name,cities.0.name,cities.1.name,capital\n" +
+		"Norway,5379475,FIN;SWE;RUS,Norwegian krone,Oslo,\"Bergen, Vestland\",\n"
+	if got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+}
+
+func TestXML(t *testing.T) {
+	got := encode(t, XML, map[string]interface{}{"code": "NO", "1st": "a&b", "values": []int{1, 2}}, "")
+	for _, want := range []string{
+		"<response>",
+		"<code>NO</code>",
+		`<entry key="1st">a&amp;b</entry>`,
+		"<values>\n    <item>1</item>\n    <item>2</item>\n  </values>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("XML missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestYAML(t *testing.T) {
+	got := encode(t, YAML, sampleCountry(), "")
+	for _, want := range []string{
+		"name: Norway\npopulation: 5379475\n",
+		"  - FIN\n",
+		"  NOK:\n    name: Norwegian krone\n",
+		"capital: null\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("YAML missing %q:\n%s", want, got)
+		}
+	}
+
+	// Strings that look like other types stay strings
+	if got := encode(t, YAML, map[string]string{"code": "NO", "year": "2020"}, ""); got != "code: \"NO\"\nyear: \"2020\"\n" {
+		t.Errorf("YAML = %q", got)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	got := encode(t, NDJSON, samplePopulation(), "values")
+	want := "{\"year\":2019,\"value\":100}\n{\"year\":2020,\"value\":200}\n"
+	if got != want {
+		t.Errorf("NDJSON = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// member is a key and value of a JSON object.
+type member struct {
+	Key   string
+	Value interface{}
+}
+
+// object is a JSON object with its members in encoding order.
+type object []member
+
+// get returns the value of the member with the given key.
+func (o object) get(key string) (interface{}, bool) {
+	for _, m := range o {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// toTree encodes v as JSON and decodes it into a tree of object, []interface{}, string, json.Number, bool and
+// nil values. Going through JSON means every format uses the same field names, order and omissions as JSON.
+func toTree(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeValue(decoder)
+}
+
+// decodeValue reads the next JSON value from the decoder, keeping the order of object members.
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{Key: key.(string), Value: value})
+		}
+		_, err := decoder.Token() // Closing brace
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token() // Closing bracket
+		return list, err
+	case json.Delim('}'), json.Delim(']'):
+		return nil, fmt.Errorf("unexpected %v", token)
+	}
+	return token, nil
+}
+
+// scalarText returns the text of a scalar value; null is empty.
+func scalarText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	}
+	return fmt.Sprint(value)
+}
+
+// isScalar reports whether the value is neither an object nor an array.
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case object, []interface{}:
+		return false
+	}
+	return true
+}
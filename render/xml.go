@@ -0,0 +1,66 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// xmlName matches names that can be used as XML element names as they are.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// encodeXML writes v as an XML document with a <response> root element. Object members become elements named
+// after their JSON field, list items become <item> elements, and members whose key is not a valid element name
+// (e.g., some map keys) become <entry key="..."> elements.
+func encodeXML(w io.Writer, v interface{}, list string) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(w)
+	writer.WriteString(xml.Header)
+	if err := writeXMLElement(writer, "response", tree, 0); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// writeXMLElement writes a value as an element with the given name, indented by depth.
+func writeXMLElement(w *bufio.Writer, name string, value interface{}, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	start, end := "<"+name+">", "</"+name+">"
+	if !xmlName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		var key strings.Builder
+		xml.EscapeText(&key, []byte(name))
+		start, end = `<entry key="`+key.String()+`">`, "</entry>"
+	}
+
+	switch v := value.(type) {
+	case object:
+		w.WriteString(indent + start + "\n")
+		for _, m := range v {
+			if err := writeXMLElement(w, m.Key, m.Value, depth+1); err != nil {
+				return err
+			}
+		}
+		w.WriteString(indent + end + "\n")
+	case []interface{}:
+		w.WriteString(indent + start + "\n")
+		for _, item := range v {
+			if err := writeXMLElement(w, "item", item, depth+1); err != nil {
+				return err
+			}
+		}
+		w.WriteString(indent + end + "\n")
+	default:
+		w.WriteString(indent + start)
+		if err := xml.EscapeText(w, []byte(scalarText(v))); err != nil {
+			return err
+		}
+		w.WriteString(end + "\n")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// encodeYAML writes v as a YAML document, with the same fields and field order as JSON.
+func encodeYAML(w io.Writer, v interface{}, list string) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(tree)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// yaml11Booleans are strings that YAML 1.1 parsers read as booleans. They are quoted, so that e.g. the
+// country code "NO" is not read as false.
+var yaml11Booleans = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true,
+}
+
+// yamlNode converts a tree value to a YAML node. Strings are tagged explicitly so that values such as "123"
+// stay strings.
+func yamlNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case object:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, m := range v {
+			node.Content = append(node.Content, yamlNode(m.Key), yamlNode(m.Value))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+		if yaml11Booleans[strings.ToLower(v)] {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		return node
+	case json.Number:
+		tag := "!!float"
+		if _, err := v.Int64(); err == nil {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: scalarText(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
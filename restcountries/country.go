@@ -0,0 +1,120 @@
+// Package restcountries contains a typed model of the REST Countries v3.1 API and its decoding.
+package restcountries
+
+// Country represents a single country record from the REST Countries v3.1 API.
+type Country struct {
+	Name         Name                   `json:"name"`
+	TLD          []string               `json:"tld"`
+	CCA2         string                 `json:"cca2"`
+	CCN3         string                 `json:"ccn3"`
+	CCA3         string                 `json:"cca3"`
+	CIOC         string                 `json:"cioc"`
+	Independent  bool                   `json:"independent"`
+	Status       string                 `json:"status"`
+	UNMember     bool                   `json:"unMember"`
+	Currencies   map[string]Currency    `json:"currencies"`
+	IDD          IDD                    `json:"idd"`
+	Capital      []string               `json:"capital"`
+	AltSpellings []string               `json:"altSpellings"`
+	Region       string                 `json:"region"`
+	Subregion    string                 `json:"subregion"`
+	Languages    map[string]string      `json:"languages"`
+	Translations map[string]Translation `json:"translations"`
+	LatLng       []float64              `json:"latlng"`
+	Landlocked   bool                   `json:"landlocked"`
+	Borders      []string               `json:"borders"`
+	Area         float64                `json:"area"`
+	Demonyms     map[string]Demonym     `json:"demonyms"`
+	Flag         string                 `json:"flag"`
+	Maps         Maps                   `json:"maps"`
+	Population   int                    `json:"population"`
+	Gini         map[string]float64     `json:"gini"`
+	FIFA         string                 `json:"fifa"`
+	Car          Car                    `json:"car"`
+	Timezones    []string               `json:"timezones"`
+	Continents   []string               `json:"continents"`
+	Flags        Flags                  `json:"flags"`
+	CoatOfArms   CoatOfArms             `json:"coatOfArms"`
+	StartOfWeek  string                 `json:"startOfWeek"`
+	CapitalInfo  CapitalInfo            `json:"capitalInfo"`
+	PostalCode   PostalCode             `json:"postalCode"`
+}
+
+// Name holds the common and official names of a country, and its names in native languages.
+type Name struct {
+	Common     string                 `json:"common"`
+	Official   string                 `json:"official"`
+	NativeName map[string]Translation `json:"nativeName"`
+}
+
+// Translation is the official and common name of a country in one language.
+type Translation struct {
+	Official string `json:"official"`
+	Common   string `json:"common"`
+}
+
+// Currency describes a currency used in a country.
+type Currency struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+}
+
+// IDD holds the international direct dialing root and suffixes of a country.
+type IDD struct {
+	Root     string   `json:"root"`
+	Suffixes []string `json:"suffixes"`
+}
+
+// Demonym holds the female and male demonyms in one language.
+type Demonym struct {
+	F string `json:"f"`
+	M string `json:"m"`
+}
+
+// Maps holds links to the country on Google Maps and OpenStreetMap.
+type Maps struct {
+	GoogleMaps     string `json:"googleMaps"`
+	OpenStreetMaps string `json:"openStreetMaps"`
+}
+
+// Car holds the driving side and international vehicle registration codes.
+type Car struct {
+	Signs []string `json:"signs"`
+	Side  string   `json:"side"`
+}
+
+// Flags holds links to flag images and a textual description of the flag.
+type Flags struct {
+	PNG string `json:"png"`
+	SVG string `json:"svg"`
+	Alt string `json:"alt"`
+}
+
+// CoatOfArms holds links to coat of arms images.
+type CoatOfArms struct {
+	PNG string `json:"png"`
+	SVG string `json:"svg"`
+}
+
+// CapitalInfo holds the coordinates of the capital.
+type CapitalInfo struct {
+	LatLng []float64 `json:"latlng"`
+}
+
+// PostalCode holds the postal code format and a regular expression matching it.
+type PostalCode struct {
+	Format string `json:"format"`
+	Regex  string `json:"regex"`
+}
+
+// CallingCodes returns the full international calling codes (root followed by each suffix).
+func (c Country) CallingCodes() []string {
+	codes := []string{}
+	for _, suffix := range c.IDD.Suffixes {
+		codes = append(codes, c.IDD.Root+suffix)
+	}
+	if len(codes) == 0 && c.IDD.Root != "" {
+		codes = append(codes, c.IDD.Root)
+	}
+	return codes
+}
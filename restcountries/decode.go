@@ -0,0 +1,124 @@
+package restcountries
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// APIError is returned when the REST Countries API answers with an error object instead of countries.
+type APIError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("REST Countries API error %d: %s", e.Status, e.Message)
+}
+
+// Decode reads a REST Countries response containing an array of countries, such as /v3.1/all.
+//
+// Unknown fields are ignored, so additions to the upstream API do not break decoding. A record with a field of
+// an unexpected type, or without a common name or country codes, is skipped, so one bad record does not make the
+// whole dataset unusable; the skipped records are returned as errors with their index. The response as a whole is
+// strict: it must be an array with no data after it, and at least one record of a non-empty array must be valid.
+func Decode(r io.Reader) ([]Country, []error, error) {
+	records, err := decodeRecords(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	countries := make([]Country, 0, len(records))
+	var skipped []error
+	for i, record := range records {
+		country, err := decodeCountry(record)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("invalid country at index %d: %w", i, err))
+			continue
+		}
+		countries = append(countries, country)
+	}
+	if len(records) > 0 && len(countries) == 0 {
+		return nil, skipped, fmt.Errorf("no valid countries among %d records: %w", len(records), skipped[0])
+	}
+
+	return countries, skipped, nil
+}
+
+// DecodeOne reads a response expected to contain exactly one country, such as /v3.1/alpha/{code}.
+// Unlike Decode, it rejects the response if the record has a field of an unexpected type or misses its name
+// or country codes.
+func DecodeOne(r io.Reader) (*Country, error) {
+	records, err := decodeRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != 1 {
+		return nil, fmt.Errorf("expected 1 country, got %d", len(records))
+	}
+	country, err := decodeCountry(records[0])
+	if err != nil {
+		return nil, err
+	}
+	return &country, nil
+}
+
+// decodeRecords reads the array of a REST Countries response, leaving its records encoded.
+func decodeRecords(r io.Reader) ([]json.RawMessage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("empty response")
+	}
+
+	// The API reports errors such as unknown codes as an object instead of an array
+	if data[0] == '{' {
+		var apiErr APIError
+		if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Status != 0 {
+			return nil, &apiErr
+		}
+		return nil, errors.New("expected a JSON array of countries, got an object")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var records []json.RawMessage
+	if err := decoder.Decode(&records); err != nil {
+		return nil, fmt.Errorf("invalid country data: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after country array")
+	}
+	return records, nil
+}
+
+// decodeCountry decodes and validates a single record.
+func decodeCountry(record json.RawMessage) (Country, error) {
+	var country Country
+	if err := json.Unmarshal(record, &country); err != nil {
+		return Country{}, fmt.Errorf("invalid country data: %w", err)
+	}
+	if err := country.validate(); err != nil {
+		return Country{}, err
+	}
+	return country, nil
+}
+
+// validate checks that the fields every record is expected to have are present.
+func (c Country) validate() error {
+	if c.Name.Common == "" {
+		return errors.New("missing name.common")
+	}
+	if len(c.CCA2) != 2 {
+		return fmt.Errorf("%s: invalid cca2 %q", c.Name.Common, c.CCA2)
+	}
+	if len(c.CCA3) != 3 {
+		return fmt.Errorf("%s: invalid cca3 %q", c.Name.Common, c.CCA3)
+	}
+	return nil
+}
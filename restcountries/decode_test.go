@@ -0,0 +1,162 @@
+package restcountries
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// openFixture opens a recorded API response from the testdata directory.
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to open fixture %s: %v", name, err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestDecodeOneNorway(t *testing.T) {
+	country, err := DecodeOne(openFixture(t, "norway.json"))
+	if err != nil {
+		t.Fatalf("DecodeOne returned error: %v", err)
+	}
+
+	if country.Name.Common != "Norway" || country.Name.Official != "Kingdom of Norway" {
+		t.Errorf("unexpected name: %+v", country.Name)
+	}
+	if got := country.Name.NativeName["nob"].Common; got != "Norge" {
+		t.Errorf("nativeName nob = %q, want %q", got, "Norge")
+	}
+	if country.CCA2 != "NO" || country.CCA3 != "NOR" || country.CCN3 != "578" {
+		t.Errorf("unexpected codes: %s %s %s", country.CCA2, country.CCA3, country.CCN3)
+	}
+	if country.Population != 5379475 {
+		t.Errorf("population = %d, want 5379475", country.Population)
+	}
+	if country.Area != 323802 {
+		t.Errorf("area = %v, want 323802", country.Area)
+	}
+	if !reflect.DeepEqual(country.Borders, []string{"FIN", "SWE", "RUS"}) {
+		t.Errorf("borders = %v", country.Borders)
+	}
+	if got := country.Currencies["NOK"]; got != (Currency{Name: "Norwegian krone", Symbol: "kr"}) {
+		t.Errorf("currency NOK = %+v", got)
+	}
+	if !reflect.DeepEqual(country.CallingCodes(), []string{"+47"}) {
+		t.Errorf("calling codes = %v", country.CallingCodes())
+	}
+	if !reflect.DeepEqual(country.CapitalInfo.LatLng, []float64{59.92, 10.75}) {
+		t.Errorf("capital latlng = %v", country.CapitalInfo.LatLng)
+	}
+	if country.Gini["2018"] != 27.6 {
+		t.Errorf("gini 2018 = %v, want 27.6", country.Gini["2018"])
+	}
+	if country.Car.Side != "right" || country.Landlocked || !country.UNMember {
+		t.Errorf("unexpected car side, landlocked or UN membership: %+v", country)
+	}
+}
+
+func TestDecodeSample(t *testing.T) {
+	countries, skipped, err := Decode(openFixture(t, "sample_all.json"))
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("Decode returned error: %v (skipped %v)", err, skipped)
+	}
+	if len(countries) != 3 {
+		t.Fatalf("got %d countries, want 3", len(countries))
+	}
+
+	lesotho, iceland, antarctica := countries[0], countries[1], countries[2]
+	if !lesotho.Landlocked || len(lesotho.Currencies) != 2 {
+		t.Errorf("unexpected Lesotho record: %+v", lesotho)
+	}
+	if iceland.Borders != nil {
+		t.Errorf("Iceland borders = %v, want none", iceland.Borders)
+	}
+	if len(antarctica.Capital) != 0 || len(antarctica.CallingCodes()) != 0 {
+		t.Errorf("Antarctica should have no capital or calling codes: %v %v", antarctica.Capital, antarctica.CallingCodes())
+	}
+}
+
+func TestDecodeIgnoresUnknownFields(t *testing.T) {
+	country, err := DecodeOne(openFixture(t, "unknown_fields.json"))
+	if err != nil {
+		t.Fatalf("DecodeOne returned error: %v", err)
+	}
+	// A "name" key nested inside "name" must not be mistaken for the common name
+	if country.Name.Common != "Norway" {
+		t.Errorf("name.common = %q, want %q", country.Name.Common, "Norway")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"wrong_type.json", "invalid country data"},
+		{"missing_codes.json", "invalid cca2"},
+		{"not_found.json", "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			_, err := DecodeOne(openFixture(t, tt.fixture))
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+
+			// Without a single valid record, the bulk decode fails too
+			if _, _, err := Decode(openFixture(t, tt.fixture)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Decode error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSkipsInvalidRecords(t *testing.T) {
+	countries, skipped, err := Decode(strings.NewReader(`[
+		{"name":{"common":"Norway"},"cca2":"NO","cca3":"NOR"},
+		{"name":{"common":"Sweden"},"cca2":"SE","cca3":"SWE","population":"10353442"},
+		{"name":{"common":"Finland"},"cca2":"","cca3":"FIN"},
+		{"name":{"common":"Iceland"},"cca2":"IS","cca3":"ISL"}
+	]`))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if len(countries) != 2 || countries[0].CCA2 != "NO" || countries[1].CCA2 != "IS" {
+		t.Errorf("countries = %+v, want Norway and Iceland", countries)
+	}
+	if len(skipped) != 2 || !strings.Contains(skipped[0].Error(), "index 1") || !strings.Contains(skipped[1].Error(), "index 2") {
+		t.Errorf("skipped = %v, want the records at index 1 and 2", skipped)
+	}
+}
+
+func TestDecodeAPIError(t *testing.T) {
+	_, _, err := Decode(openFixture(t, "not_found.json"))
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != 404 {
+		t.Fatalf("expected *APIError with status 404, got %v", err)
+	}
+}
+
+func TestDecodeRejectsTrailingData(t *testing.T) {
+	_, _, err := Decode(strings.NewReader(`[{"name":{"common":"Norway"},"cca2":"NO","cca3":"NOR"}] []`))
+	if err == nil {
+		t.Fatal("expected error for trailing data, got nil")
+	}
+}
+
+func TestDecodeOneRequiresSingleCountry(t *testing.T) {
+	_, err := DecodeOne(openFixture(t, "sample_all.json"))
+	if err == nil {
+		t.Fatal("expected error for multiple countries, got nil")
+	}
+}
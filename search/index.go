@@ -0,0 +1,149 @@
+// Package search implements fuzzy country name search over the names and spellings in REST Countries.
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"country-info-service/restcountries"
+)
+
+// Kinds of names a query can match, in the order they are preferred.
+const (
+	KindCommon      = "common"
+	KindOfficial    = "official"
+	KindNative      = "native"
+	KindAlternative = "alternative"
+	KindTranslation = "translation"
+)
+
+// kindWeights lowers the score of matches on less prominent names.
+var kindWeights = map[string]float64{
+	KindCommon:      1.0,
+	KindOfficial:    0.95,
+	KindNative:      0.9,
+	KindAlternative: 0.9,
+	KindTranslation: 0.85,
+}
+
+// entry is a single searchable name of a country.
+type entry struct {
+	code       string // ISO3 code of the country
+	name       string // The name as given by REST Countries
+	normalized []rune // The normalized name
+	kind       string
+}
+
+// Index holds every searchable name of a set of countries.
+type Index struct {
+	entries    []entry
+	population map[string]int
+}
+
+// Match is a country matching a query, with the name that matched best.
+type Match struct {
+	Code  string  // ISO3 code of the country
+	Name  string  // The name that matched
+	Kind  string  // The kind of name that matched
+	Score float64 // Between 0 and 1, where 1 is an exact match on the common name
+}
+
+// NewIndex builds a search index over the common, official, native, alternative and translated names of countries.
+func NewIndex(countries []restcountries.Country) *Index {
+	idx := &Index{population: make(map[string]int, len(countries))}
+	for _, c := range countries {
+		idx.population[c.CCA3] = c.Population
+		idx.add(c.CCA3, c.Name.Common, KindCommon)
+		idx.add(c.CCA3, c.Name.Official, KindOfficial)
+		for _, native := range c.Name.NativeName {
+			idx.add(c.CCA3, native.Common, KindNative)
+			idx.add(c.CCA3, native.Official, KindNative)
+		}
+		for _, spelling := range c.AltSpellings {
+			idx.add(c.CCA3, spelling, KindAlternative)
+		}
+		for _, translation := range c.Translations {
+			idx.add(c.CCA3, translation.Common, KindTranslation)
+			idx.add(c.CCA3, translation.Official, KindTranslation)
+		}
+	}
+	return idx
+}
+
+// add indexes a name, skipping empty names.
+func (idx *Index) add(code, name, kind string) {
+	normalized := normalize(name)
+	if normalized == "" {
+		return
+	}
+	idx.entries = append(idx.entries, entry{code: code, name: name, normalized: []rune(normalized), kind: kind})
+}
+
+// Search returns up to limit countries matching the query, best match first. Exact matches score highest,
+// then names starting with the query, then names containing a word starting with the query, and finally
+// names within a few typos of the query. Countries with equal scores are ordered by population.
+func (idx *Index) Search(query string, limit int) []Match {
+	q := []rune(normalize(query))
+	if len(q) == 0 {
+		return nil
+	}
+
+	best := map[string]Match{}
+	for _, e := range idx.entries {
+		score := matchScore(q, e.normalized) * kindWeights[e.kind]
+		if score == 0 {
+			continue
+		}
+		if current, ok := best[e.code]; !ok || score > current.Score {
+			best[e.code] = Match{Code: e.code, Name: e.name, Kind: e.kind, Score: score}
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if pi, pj := idx.population[matches[i].Code], idx.population[matches[j].Code]; pi != pj {
+			return pi > pj
+		}
+		return matches[i].Code < matches[j].Code
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// matchScore rates how well the normalized query matches a normalized name, from 0 (no match) to 1.
+func matchScore(query, name []rune) float64 {
+	q, n := string(query), string(name)
+	switch {
+	case q == n:
+		return 1
+	case strings.HasPrefix(n, q):
+		// Prefer names where the query covers more of the name
+		return 0.8 + 0.1*float64(len(query))/float64(len(name))
+	case strings.Contains(n, " "+q):
+		return 0.7 + 0.1*float64(len(query))/float64(len(name))
+	}
+
+	// Tolerate typos, comparing against the whole name and against its beginning,
+	// so that partially typed names still match
+	allowed := maxTypos(len(query))
+	if allowed == 0 {
+		return 0
+	}
+	distance := editDistance(query, name)
+	if len(name) > len(query) {
+		distance = min(distance, editDistance(query, name[:len(query)]))
+	}
+	if distance > allowed {
+		return 0
+	}
+	return 0.6 * (1 - float64(distance)/float64(len(query)+1))
+}
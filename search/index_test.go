@@ -0,0 +1,109 @@
+package search
+
+import (
+	"testing"
+
+	"country-info-service/restcountries"
+)
+
+// testIndex builds an index over a few countries with native names, spellings and translations.
+func testIndex() *Index {
+	return NewIndex([]restcountries.Country{
+		{
+			Name: restcountries.Name{
+				Common:     "Norway",
+				Official:   "Kingdom of Norway",
+				NativeName: map[string]restcountries.Translation{"nob": {Official: "Kongeriket Norge", Common: "Norge"}},
+			},
+			CCA3:         "NOR",
+			AltSpellings: []string{"NO", "Noreg"},
+			Translations: map[string]restcountries.Translation{"deu": {Official: "Königreich Norwegen", Common: "Norwegen"}},
+			Population:   5379475,
+		},
+		{
+			Name:         restcountries.Name{Common: "North Korea", Official: "Democratic People's Republic of Korea"},
+			CCA3:         "PRK",
+			Translations: map[string]restcountries.Translation{"fra": {Official: "République populaire démocratique de Corée", Common: "Corée du Nord"}},
+			Population:   25778815,
+		},
+		{
+			Name:       restcountries.Name{Common: "Ivory Coast", Official: "Republic of Côte d'Ivoire"},
+			CCA3:       "CIV",
+			Population: 26378275,
+		},
+		{
+			Name:       restcountries.Name{Common: "Germany", Official: "Federal Republic of Germany"},
+			CCA3:       "DEU",
+			Population: 83240525,
+		},
+	})
+}
+
+func TestSearch(t *testing.T) {
+	idx := testIndex()
+
+	tests := []struct {
+		query    string
+		wantCode string
+		wantKind string
+	}{
+		{"norway", "NOR", KindCommon},          // exact
+		{"norw", "NOR", KindCommon},            // prefix
+		{"Norge", "NOR", KindNative},           // native name
+		{"noreg", "NOR", KindAlternative},      // alternative spelling
+		{"norwegen", "NOR", KindTranslation},   // translation
+		{"nroway", "NOR", KindCommon},          // transposition typo
+		{"germnay", "DEU", KindCommon},         // transposition typo
+		{"cote d'ivoire", "CIV", KindOfficial}, // word inside official name, accents folded
+		{"korea", "PRK", KindCommon},           // word prefix
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			matches := idx.Search(tt.query, 3)
+			if len(matches) == 0 {
+				t.Fatalf("Search(%q) returned no matches", tt.query)
+			}
+			if matches[0].Code != tt.wantCode || matches[0].Kind != tt.wantKind {
+				t.Errorf("Search(%q) best match = %s (%s), want %s (%s)", tt.query, matches[0].Code, matches[0].Kind, tt.wantCode, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestSearchShortQueryHasNoTypos(t *testing.T) {
+	matches := testIndex().Search("nor", 10)
+	// "nor" is too short for typos, so only names starting with it or with a word starting with it match
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches for %q, got %+v", "nor", matches)
+	}
+	for i, m := range matches {
+		if m.Code != "NOR" && m.Code != "PRK" {
+			t.Errorf("unexpected match %s (%s) for %q", m.Code, m.Name, "nor")
+		}
+		if i > 0 && m.Score > matches[i-1].Score {
+			t.Errorf("matches not ordered by score: %+v", matches)
+		}
+	}
+}
+
+func TestSearchNoMatch(t *testing.T) {
+	if matches := testIndex().Search("xyzzy", 5); len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+	if matches := testIndex().Search("  ", 5); matches != nil {
+		t.Errorf("expected nil for empty query, got %+v", matches)
+	}
+}
+
+func TestNormalizeAndEditDistance(t *testing.T) {
+	if got := normalize("  Côte d'Ivoire "); got != "cote d ivoire" {
+		t.Errorf("normalize = %q", got)
+	}
+	if got := editDistance([]rune("nroway"), []rune("norway")); got != 1 {
+		t.Errorf("editDistance(nroway, norway) = %d, want 1", got)
+	}
+	if got := editDistance([]rune("kitten"), []rune("sitting")); got != 3 {
+		t.Errorf("editDistance(kitten, sitting) = %d, want 3", got)
+	}
+}
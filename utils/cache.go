@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheTTL is how long an upstream response is reused before it is fetched again.
+var CacheTTL = 1 * time.Hour
+
+// cacheEntry holds a raw upstream response body and the time it was fetched.
+type cacheEntry struct {
+	body      []byte
+	fetchedAt time.Time
+	decoded   interface{} // The body as decoded by fetchDecoded, if it was fetched through it
+}
+
+// upstreamCache stores upstream response bodies keyed by request (usually the URL).
+var upstreamCache = struct {
+	sync.RWMutex
+	entries map[string]cacheEntry
+}{entries: make(map[string]cacheEntry)}
+
+// ErrMaintenance is returned instead of calling an upstream API while maintenance mode is on.
+var ErrMaintenance = errors.New("upstream APIs are in maintenance mode")
+
+// maintenance is set while the service is in upstream maintenance mode.
+var maintenance atomic.Bool
+
+// SetMaintenance turns upstream maintenance mode on or off. While it is on, no upstream API is called:
+// cached responses are served however old they are, the bulk dataset falls back to its snapshot, and
+// anything else fails with ErrMaintenance.
+func SetMaintenance(enabled bool) {
+	maintenance.Store(enabled)
+}
+
+// Maintenance reports whether upstream maintenance mode is on.
+func Maintenance() bool {
+	return maintenance.Load()
+}
+
+// fetchCached returns the cached body for key if it is still fresh (or if in maintenance mode), otherwise it
+// calls fetch and stores the result. The time the body was fetched from upstream is returned with it. If fetch
+// fails while an expired body is cached, that body is served instead and the failure is logged, so the service
+// keeps working while an upstream API is down.
+func fetchCached(key string, fetch func() ([]byte, error)) ([]byte, time.Time, error) {
+	upstreamCache.RLock()
+	entry, ok := upstreamCache.entries[key]
+	upstreamCache.RUnlock()
+	if ok && (time.Since(entry.fetchedAt) < CacheTTL || Maintenance()) {
+		return entry.body, entry.fetchedAt, nil
+	}
+
+	body, err := fetch()
+	if err != nil {
+		if ok {
+			log.Printf("Warning: serving %s from %s, refreshing it failed: %v", key, entry.fetchedAt.Format(time.RFC3339), err)
+			return entry.body, entry.fetchedAt, nil
+		}
+		return nil, time.Time{}, err
+	}
+
+	entry = cacheEntry{body: body, fetchedAt: time.Now()}
+	upstreamCache.Lock()
+	upstreamCache.entries[key] = entry
+	upstreamCache.Unlock()
+
+	return entry.body, entry.fetchedAt, nil
+}
+
+// fetchDecoded is fetchCached for responses that are expensive to decode: the body is decoded once, when it
+// is stored, and the decoded value is kept next to it for later calls. A body that fails to decode is not
+// stored, so the next call fetches it again.
+func fetchDecoded(key string, fetch func() ([]byte, error), decode func([]byte) (interface{}, error)) (interface{}, time.Time, error) {
+	upstreamCache.RLock()
+	entry, ok := upstreamCache.entries[key]
+	upstreamCache.RUnlock()
+	if ok && entry.decoded != nil && (time.Since(entry.fetchedAt) < CacheTTL || Maintenance()) {
+		return entry.decoded, entry.fetchedAt, nil
+	}
+
+	body, fetchedAt, err := fetchCached(key, fetch)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if ok && entry.decoded != nil && fetchedAt.Equal(entry.fetchedAt) {
+		// The refresh failed and the expired entry is served, which is already decoded
+		return entry.decoded, fetchedAt, nil
+	}
+	decoded, err := decode(body)
+	if err != nil {
+		upstreamCache.Lock()
+		delete(upstreamCache.entries, key)
+		upstreamCache.Unlock()
+		return nil, time.Time{}, err
+	}
+
+	upstreamCache.Lock()
+	upstreamCache.entries[key] = cacheEntry{body: body, fetchedAt: fetchedAt, decoded: decoded}
+	upstreamCache.Unlock()
+	return decoded, fetchedAt, nil
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"country-info-service/restcountries"
+)
+
+// CacheEntryInfo describes an entry of the upstream cache.
+type CacheEntryInfo struct {
+	Key       string    `json:"key"`  // The upstream URL, followed by the JSON payload for POST requests
+	Size      int       `json:"size"` // Size of the response body in bytes
+	FetchedAt time.Time `json:"fetchedAt"`
+	Age       int       `json:"age"`   // Seconds since the entry was fetched
+	Fresh     bool      `json:"fresh"` // Whether the entry is reused without asking upstream again
+}
+
+// CacheResponse describes the contents of the upstream cache.
+type CacheResponse struct {
+	TTL         int              `json:"ttl"` // CacheTTL in seconds
+	Maintenance bool             `json:"maintenance"`
+	Count       int              `json:"count"`
+	TotalSize   int              `json:"totalSize"` // Size of all response bodies in bytes
+	Entries     []CacheEntryInfo `json:"entries"`
+}
+
+// PurgeResponse lists the cache entries that were removed.
+type PurgeResponse struct {
+	Country string   `json:"country,omitempty"` // ISO2 code, if the entries of a single country were removed
+	Name    string   `json:"name,omitempty"`
+	Count   int      `json:"count"`
+	Keys    []string `json:"keys"`
+}
+
+// WarmResponse describes the cache entries of a country after warming them.
+type WarmResponse struct {
+	Country  string    `json:"country"`
+	Name     string    `json:"name"`
+	Count    int       `json:"count"` // Cache entries for the country
+	Size     int       `json:"size"`  // Size of their response bodies in bytes
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+// CacheContents describes the entries of the upstream cache whose key contains filter, ordered by key.
+// An empty filter matches every entry.
+func CacheContents(filter string) *CacheResponse {
+	upstreamCache.RLock()
+	defer upstreamCache.RUnlock()
+
+	response := &CacheResponse{
+		TTL:         int(CacheTTL.Seconds()),
+		Maintenance: Maintenance(),
+		Entries:     []CacheEntryInfo{},
+	}
+	for key, entry := range upstreamCache.entries {
+		if !strings.Contains(key, filter) {
+			continue
+		}
+		age := time.Since(entry.fetchedAt)
+		response.Entries = append(response.Entries, CacheEntryInfo{
+			Key:       key,
+			Size:      len(entry.body),
+			FetchedAt: entry.fetchedAt,
+			Age:       int(age.Seconds()),
+			Fresh:     age < CacheTTL,
+		})
+		response.TotalSize += len(entry.body)
+	}
+	sort.Slice(response.Entries, func(i, j int) bool { return response.Entries[i].Key < response.Entries[j].Key })
+	response.Count = len(response.Entries)
+
+	return response
+}
+
+// PurgeCache removes every entry from the upstream cache.
+func PurgeCache() *PurgeResponse {
+	return purge(func(string) bool { return true })
+}
+
+// PurgeCountry removes the cache entries of the country with the given ISO2 or ISO3 code: its REST Countries
+// record and the CountriesNow responses about it (population, cities and states). The bulk datasets shared by
+// all countries are kept.
+func PurgeCountry(code string) (*PurgeResponse, error) {
+	country, err := resolveCountry(code)
+	if err != nil {
+		return nil, err
+	}
+
+	response := purge(func(key string) bool { return isCountryKey(key, country) })
+	response.Country = country.CCA2
+	response.Name = country.Name.Common
+	return response, nil
+}
+
+// WarmCountry fetches everything the country endpoints need into the cache: the country's record, population,
+// cities and states. Entries that are still fresh are not fetched again. Resources that could not be fetched
+// are reported as warnings.
+func WarmCountry(code string) (*WarmResponse, error) {
+	country, err := resolveCountry(code)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := fetchCountry(country.CCA2); err != nil {
+		return nil, err
+	}
+
+	var warnings []Warning
+	if _, err := FetchPopulationData(country.CCA2, 0, 0); err != nil {
+		warnings = append(warnings, NewWarning("population", err))
+	}
+	_, cityWarnings, err := fetchEnrichedCities(country.Name.Common, true)
+	if err != nil {
+		warnings = append(warnings, NewWarning("cities", err))
+	}
+	warnings = append(warnings, cityWarnings...)
+	if _, err := fetchStates(country.Name.Common); err != nil {
+		warnings = append(warnings, NewWarning("states", err))
+	}
+
+	response := &WarmResponse{Country: country.CCA2, Name: country.Name.Common, Warnings: warnings}
+	for _, entry := range CacheContents("").Entries {
+		if isCountryKey(entry.Key, country) {
+			response.Count++
+			response.Size += entry.Size
+		}
+	}
+	return response, nil
+}
+
+// resolveCountry finds a country in the cached REST Countries dataset by its ISO2 or ISO3 code.
+func resolveCountry(code string) (restcountries.Country, error) {
+	index, err := countriesByISO3()
+	if err != nil {
+		return restcountries.Country{}, fmt.Errorf("failed to get countries: %w", err)
+	}
+	country, ok := lookupCountry(index, code)
+	if !ok {
+		return restcountries.Country{}, fmt.Errorf("country not found for code: %s", code)
+	}
+	return country, nil
+}
+
+// purge removes the cache entries whose key matches and lists them, ordered by key.
+func purge(match func(key string) bool) *PurgeResponse {
+	upstreamCache.Lock()
+	defer upstreamCache.Unlock()
+
+	keys := []string{}
+	for key := range upstreamCache.entries {
+		if match(key) {
+			keys = append(keys, key)
+			delete(upstreamCache.entries, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return &PurgeResponse{Count: len(keys), Keys: keys}
+}
+
+// isCountryKey reports whether a cache key is a request about the country: its REST Countries record by ISO2
+// or ISO3 code, or a CountriesNow POST request with the country's name in the payload.
+func isCountryKey(key string, country restcountries.Country) bool {
+	if code, ok := strings.CutPrefix(key, countryURL); ok {
+		return strings.EqualFold(code, country.CCA2) || strings.EqualFold(code, country.CCA3)
+	}
+
+	_, payload, found := strings.Cut(key, " ")
+	if !found {
+		return false
+	}
+	var request struct {
+		Country string `json:"country"`
+	}
+	return json.Unmarshal([]byte(payload), &request) == nil && request.Country == country.Name.Common
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFetchDecoded(t *testing.T) {
+	defer PurgeCache()
+	PurgeCache()
+
+	fetches, decodes := 0, 0
+	fetch := func() ([]byte, error) { fetches++; return []byte("42"), nil }
+	decode := func(body []byte) (interface{}, error) { decodes++; return string(body), nil }
+	for i := 0; i < 3; i++ {
+		decoded, _, err := fetchDecoded("test:answer", fetch, decode)
+		if err != nil || decoded != "42" {
+			t.Fatalf("fetchDecoded = %v, %v", decoded, err)
+		}
+	}
+	if fetches != 1 || decodes != 1 {
+		t.Errorf("%d fetches and %d decodes, want the body fetched and decoded once", fetches, decodes)
+	}
+
+	// A body that fails to decode is not kept
+	failing := func([]byte) (interface{}, error) { return nil, errors.New("invalid") }
+	if _, _, err := fetchDecoded("test:invalid", fetch, failing); err == nil {
+		t.Fatal("expected the decode error")
+	}
+	if contents := CacheContents("test:invalid"); contents.Count != 0 {
+		t.Errorf("a body that failed to decode was cached: %+v", contents)
+	}
+}
+
+func TestFetchCachedStale(t *testing.T) {
+	defer PurgeCache()
+	PurgeCache()
+
+	fetched := func() ([]byte, error) { return []byte("old"), nil }
+	if _, _, err := fetchCached("test:stale", fetched); err != nil {
+		t.Fatal(err)
+	}
+	// Expire the entry
+	upstreamCache.Lock()
+	entry := upstreamCache.entries["test:stale"]
+	entry.fetchedAt = entry.fetchedAt.Add(-2 * CacheTTL)
+	upstreamCache.entries["test:stale"] = entry
+	upstreamCache.Unlock()
+
+	// A failed refresh serves the expired body rather than an error
+	failing := func() ([]byte, error) { return nil, errors.New("connection refused") }
+	body, fetchedAt, err := fetchCached("test:stale", failing)
+	if err != nil || string(body) != "old" || !fetchedAt.Equal(entry.fetchedAt) {
+		t.Errorf("fetchCached = %q fetched at %v, %v, want the expired body", body, fetchedAt, err)
+	}
+
+	// Without a cached body the error is returned
+	if _, _, err := fetchCached("test:missing", failing); err == nil {
+		t.Error("expected the fetch error without a cached body")
+	}
+}
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"country-info-service/restcountries"
+	"country-info-service/search"
+)
+
+// populationSeries holds the population counts of a single country from the CountriesNow API.
+type populationSeries struct {
+	Country          string `json:"country"`
+	Iso3             string `json:"iso3"`
+	PopulationCounts []struct {
+		Year  int `json:"year"`
+		Value int `json:"value"`
+	} `json:"populationCounts"`
+}
+
+// fetchBody performs a GET request and returns the response body if the status is 200 OK.
+func fetchBody(url string) ([]byte, error) {
+	if Maintenance() {
+		return nil, ErrMaintenance
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Printf("Error fetching %s: %v", url, err)
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("%s returned status: %d", url, resp.StatusCode)
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response from %s: %v", url, err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return body, nil
+}
+
+// postBody sends payload as JSON in a POST request and returns the response body if the status is 200 OK.
+func postBody(url string, payload interface{}) ([]byte, error) {
+	if Maintenance() {
+		return nil, ErrMaintenance
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON request: %w", err)
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.Printf("Error sending request to %s: %v", url, err)
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("%s returned status: %d", url, resp.StatusCode)
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response from %s: %v", url, err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return body, nil
+}
+
+// countriesSnapshotFile holds the path set with SetCountriesSnapshotFile, if any.
+var countriesSnapshotFile atomic.Pointer[string]
+
+// SetCountriesSnapshotFile sets where the bulk REST Countries dataset is saved after each successful fetch.
+// If the API is unreachable the snapshot is used instead, so dataset-based queries keep working offline.
+// The snapshot is disabled until a path is set, and an empty path disables it again.
+func SetCountriesSnapshotFile(path string) {
+	countriesSnapshotFile.Store(&path)
+}
+
+// CountriesSnapshotFile returns where the bulk REST Countries dataset is saved, or "" if the snapshot is disabled.
+func CountriesSnapshotFile() string {
+	if path := countriesSnapshotFile.Load(); path != nil {
+		return *path
+	}
+	return ""
+}
+
+// countriesDataset is the decoded bulk REST Countries response, with the structures derived from it.
+type countriesDataset struct {
+	countries []restcountries.Country
+
+	searchOnce  sync.Once
+	searchIndex *search.Index
+}
+
+// search returns the search index over the countries, building it on first use.
+func (d *countriesDataset) search() *search.Index {
+	d.searchOnce.Do(func() { d.searchIndex = search.NewIndex(d.countries) })
+	return d.searchIndex
+}
+
+// fetchCountriesDataset retrieves the dataset of every country from the REST Countries API.
+// The bulk response is cached and decoded once per fetch, so repeated calls neither hit the upstream API nor
+// decode it again.
+func fetchCountriesDataset() (*countriesDataset, error) {
+	url := "http://129.241.150.113:8080/v3.1/all"
+
+	decoded, _, err := fetchDecoded(url, func() ([]byte, error) { return fetchWithSnapshot(url, CountriesSnapshotFile()) }, decodeAllCountries)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.(*countriesDataset), nil
+}
+
+// fetchAllCountries retrieves every country from the REST Countries API, as cached by fetchCountriesDataset.
+// Callers get their own slice, which they may reorder.
+func fetchAllCountries() ([]restcountries.Country, error) {
+	dataset, err := fetchCountriesDataset()
+	if err != nil {
+		return nil, err
+	}
+	return append([]restcountries.Country(nil), dataset.countries...), nil
+}
+
+// decodeAllCountries decodes the bulk REST Countries response, logging the records it skips.
+func decodeAllCountries(body []byte) (interface{}, error) {
+	countries, skipped, err := restcountries.Decode(bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error decoding REST Countries bulk response: %v", err)
+		return nil, fmt.Errorf("failed to decode country API response: %w", err)
+	}
+	for _, skip := range skipped {
+		log.Printf("Skipping record in REST Countries bulk response: %v", skip)
+	}
+	return &countriesDataset{countries: countries}, nil
+}
+
+// fetchWithSnapshot fetches url and saves the body to the snapshot file. If the fetch fails,
+// the last saved snapshot is returned instead.
+func fetchWithSnapshot(url, snapshotFile string) ([]byte, error) {
+	body, err := fetchBody(url)
+	if snapshotFile == "" {
+		return body, err
+	}
+
+	if err != nil {
+		snapshot, readErr := os.ReadFile(snapshotFile)
+		if readErr != nil {
+			return nil, err
+		}
+		log.Printf("Using snapshot %s for %s: %v", snapshotFile, url, err)
+		return snapshot, nil
+	}
+
+	if err := writeSnapshot(snapshotFile, body); err != nil {
+		log.Printf("Error saving snapshot %s: %v", snapshotFile, err)
+	}
+	return body, nil
+}
+
+// writeSnapshot saves a snapshot through a temporary file in the same directory, renamed into place once it is
+// complete, so a crash or concurrent fetch never leaves a truncated snapshot behind.
+func writeSnapshot(snapshotFile string, body []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(snapshotFile), filepath.Base(snapshotFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) // Fails harmlessly once the file is renamed
+
+	if _, err := temp.Write(body); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), snapshotFile)
+}
+
+// fetchAllPopulationSeries retrieves the population series of every country from the CountriesNow API,
+// keyed by ISO3 country code. The bulk response is cached.
+func fetchAllPopulationSeries() (map[string]populationSeries, error) {
+	url := "http://129.241.150.113:3500/api/v0.1/countries/population"
+
+	body, _, err := fetchCached(url, func() ([]byte, error) { return fetchBody(url) })
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResponse struct {
+		Error bool               `json:"error"`
+		Msg   string             `json:"msg"`
+		Data  []populationSeries `json:"data"`
+	}
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		log.Printf("Error decoding Population API bulk response: %v", err)
+		return nil, fmt.Errorf("failed to decode Population API response: %w", err)
+	}
+	if apiResponse.Error {
+		log.Printf("Population API error: %s", apiResponse.Msg)
+		return nil, fmt.Errorf("population API returned an error: %s", apiResponse.Msg)
+	}
+
+	series := make(map[string]populationSeries, len(apiResponse.Data))
+	for _, s := range apiResponse.Data {
+		series[s.Iso3] = s
+	}
+
+	return series, nil
+}
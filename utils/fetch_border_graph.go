@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"fmt"
+
+	"country-info-service/restcountries"
+)
+
+// RouteResponse represents the shortest land route between two countries.
+type RouteResponse struct {
+	From            string           `json:"from"`
+	To              string           `json:"to"`
+	BorderCrossings int              `json:"borderCrossings"`
+	Path            []CountrySummary `json:"path"`
+}
+
+// Landmass is a group of countries connected by land borders.
+type Landmass struct {
+	Size      int              `json:"size"`
+	Countries []CountrySummary `json:"countries"`
+}
+
+// LandmassesResponse represents the connected landmasses of the border graph.
+type LandmassesResponse struct {
+	Count      int        `json:"count"`
+	Landmasses []Landmass `json:"landmasses"`
+}
+
+// CountryListResponse represents a plain list of countries.
+type CountryListResponse struct {
+	Count     int              `json:"count"`
+	Countries []CountrySummary `json:"countries"`
+}
+
+// summarize turns a list of ISO3 codes into country summaries.
+func summarize(index map[string]restcountries.Country, codes []string) []CountrySummary {
+	summaries := []CountrySummary{}
+	for _, code := range codes {
+		summaries = append(summaries, newCountrySummary(index[code], 0))
+	}
+	return summaries
+}
+
+// FetchRoute finds the path with the fewest land border crossings between two countries,
+// given by ISO2 or ISO3 code.
+func FetchRoute(from, to string) (*RouteResponse, error) {
+	graph, index, err := borderGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	start, ok := lookupCountry(index, from)
+	if !ok {
+		return nil, fmt.Errorf("country not found for code: %s", from)
+	}
+	end, ok := lookupCountry(index, to)
+	if !ok {
+		return nil, fmt.Errorf("country not found for code: %s", to)
+	}
+
+	path := graph.ShortestPath(start.CCA3, end.CCA3)
+	if path == nil {
+		return nil, fmt.Errorf("no land route between %s and %s", start.CCA2, end.CCA2)
+	}
+
+	return &RouteResponse{
+		From:            start.CCA2,
+		To:              end.CCA2,
+		BorderCrossings: len(path) - 1,
+		Path:            summarize(index, path),
+	}, nil
+}
+
+// FetchLandmasses groups all countries into landmasses connected by land borders, largest first.
+// Countries without land borders form landmasses of their own and are only included if includeIslands is set.
+func FetchLandmasses(includeIslands bool) (*LandmassesResponse, error) {
+	graph, index, err := borderGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	landmasses := []Landmass{}
+	for _, component := range graph.Components() {
+		if len(component) == 1 && !includeIslands {
+			continue
+		}
+		landmasses = append(landmasses, Landmass{
+			Size:      len(component),
+			Countries: summarize(index, component),
+		})
+	}
+
+	return &LandmassesResponse{
+		Count:      len(landmasses),
+		Landmasses: landmasses,
+	}, nil
+}
+
+// FetchIslands lists the countries without any land border.
+func FetchIslands() (*CountryListResponse, error) {
+	graph, index, err := borderGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	countries := summarize(index, graph.Islands())
+	return &CountryListResponse{Count: len(countries), Countries: countries}, nil
+}
+
+// FetchEnclaves lists the landlocked countries entirely surrounded by a single other country.
+func FetchEnclaves() (*CountryListResponse, error) {
+	graph, index, err := borderGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	countries := summarize(index, graph.Enclaves())
+	return &CountryListResponse{Count: len(countries), Countries: countries}, nil
+}
@@ -0,0 +1,357 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// City represents a city with the details CountriesNow provides for it.
+// Population and state are omitted when CountriesNow has no data for the city.
+type City struct {
+	Name           string `json:"name"`
+	State          string `json:"state,omitempty"`
+	Population     int    `json:"population,omitempty"`
+	PopulationYear int    `json:"populationYear,omitempty"`
+}
+
+// State represents a state or province of a country.
+type State struct {
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+// Supported city sort orders.
+const (
+	CitySortPopulation = "population"
+	CitySortAlpha      = "alpha"
+)
+
+// CityQuery describes how to filter, sort and page a list of cities.
+type CityQuery struct {
+	Sort     string // CitySortPopulation (default) or CitySortAlpha
+	Order    string // "asc" or "desc"; defaults to largest first for population and A-Z for alpha
+	Prefix   string // Only cities whose name starts with this (case-insensitive)
+	Contains string // Only cities whose name contains this (case-insensitive)
+	Offset   int    // Number of matching cities to skip
+	Limit    int    // Maximum number of cities to return
+	States   bool   // Look up the state of each city, which takes an upstream request per state
+}
+
+// apply filters, sorts and pages cities according to the query. It returns the page and the number of
+// cities matching the filters.
+func (q CityQuery) apply(cities []City) ([]City, int) {
+	prefix, contains := strings.ToLower(q.Prefix), strings.ToLower(q.Contains)
+	matches := []City{}
+	for _, city := range cities {
+		name := strings.ToLower(city.Name)
+		if strings.HasPrefix(name, prefix) && strings.Contains(name, contains) {
+			matches = append(matches, city)
+		}
+	}
+
+	// Sort by the chosen key, breaking population ties alphabetically
+	descending := q.Order == "desc" || (q.Order == "" && q.Sort != CitySortAlpha)
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if q.Sort != CitySortAlpha && a.Population != b.Population {
+			// Cities without population data come last in either order
+			if a.Population == 0 || b.Population == 0 {
+				return b.Population == 0
+			}
+			if descending {
+				return a.Population > b.Population
+			}
+			return a.Population < b.Population
+		}
+		nameA, nameB := strings.ToLower(a.Name), strings.ToLower(b.Name)
+		if q.Sort == CitySortAlpha && descending {
+			return nameA > nameB
+		}
+		return nameA < nameB
+	})
+
+	// Apply offset and limit
+	total := len(matches)
+	start := q.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if q.Limit > 0 && start+q.Limit < total {
+		end = start + q.Limit
+	}
+
+	return matches[start:end], total
+}
+
+// EncodeCityCursor returns an opaque cursor pointing at the given offset in a list of cities.
+func EncodeCityCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// DecodeCityCursor returns the offset a cursor created by EncodeCityCursor points at.
+func DecodeCityCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(data), "offset:") {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+// flexInt decodes a JSON number or a numeric string, as CountriesNow uses both for counts.
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*n = 0
+		return nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s: %w", data, err)
+	}
+	*n = flexInt(value)
+	return nil
+}
+
+// cityPopulation holds the latest known population of a city.
+type cityPopulation struct {
+	City       string
+	Population int
+	Year       int
+}
+
+// postCountriesNow sends a cached POST request to a CountriesNow endpoint and decodes the "data" field into v.
+func postCountriesNow(path string, payload map[string]string, v interface{}) error {
+	url := "http://129.241.150.113:3500/api/v0.1/countries/" + path
+	key, _ := json.Marshal(payload)
+
+	body, _, err := fetchCached(url+" "+string(key), func() ([]byte, error) { return postBody(url, payload) })
+	if err != nil {
+		return err
+	}
+
+	var apiResponse struct {
+		Error bool            `json:"error"`
+		Msg   string          `json:"msg"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		log.Printf("Error decoding %s response: %v", path, err)
+		return fmt.Errorf("failed to parse %s response: %w", path, err)
+	}
+	if apiResponse.Error {
+		log.Printf("CountriesNow API error for %s: %s", path, apiResponse.Msg)
+		return fmt.Errorf("error fetching %s: %s", path, apiResponse.Msg)
+	}
+	if err := json.Unmarshal(apiResponse.Data, v); err != nil {
+		log.Printf("Error decoding %s data: %v", path, err)
+		return fmt.Errorf("failed to parse %s data: %w", path, err)
+	}
+
+	return nil
+}
+
+// fetchCityNames retrieves the names of all cities in a country.
+func fetchCityNames(countryName string) ([]string, error) {
+	var cities []string
+	if err := postCountriesNow("cities", map[string]string{"country": countryName}, &cities); err != nil {
+		return nil, err
+	}
+	return cities, nil
+}
+
+// fetchCityPopulations retrieves the latest population count of each city in a country that has one.
+func fetchCityPopulations(countryName string) ([]cityPopulation, error) {
+	var data []struct {
+		City             string `json:"city"`
+		PopulationCounts []struct {
+			Year  flexInt `json:"year"`
+			Value flexInt `json:"value"`
+			Sex   string  `json:"sex"`
+		} `json:"populationCounts"`
+	}
+	if err := postCountriesNow("population/cities/filter", map[string]string{"country": countryName}, &data); err != nil {
+		return nil, err
+	}
+
+	populations := []cityPopulation{}
+	for _, entry := range data {
+		latest := cityPopulation{City: entry.City}
+		for _, count := range entry.PopulationCounts {
+			// Some counts are split by sex; only use the totals
+			if count.Sex != "" && !strings.EqualFold(count.Sex, "Both Sexes") {
+				continue
+			}
+			if int(count.Year) >= latest.Year {
+				latest.Year, latest.Population = int(count.Year), int(count.Value)
+			}
+		}
+		if latest.Population > 0 {
+			populations = append(populations, latest)
+		}
+	}
+	return populations, nil
+}
+
+// fetchStates retrieves the states or provinces of a country.
+func fetchStates(countryName string) ([]State, error) {
+	var data struct {
+		States []struct {
+			Name      string `json:"name"`
+			StateCode string `json:"state_code"`
+		} `json:"states"`
+	}
+	if err := postCountriesNow("states", map[string]string{"country": countryName}, &data); err != nil {
+		return nil, err
+	}
+
+	states := []State{}
+	for _, s := range data.States {
+		states = append(states, State{Name: s.Name, Code: s.StateCode})
+	}
+	return states, nil
+}
+
+// fetchStateCities retrieves the names of the cities in a state of a country.
+func fetchStateCities(countryName, stateName string) ([]string, error) {
+	var cities []string
+	payload := map[string]string{"country": countryName, "state": stateName}
+	if err := postCountriesNow("state/cities", payload, &cities); err != nil {
+		return nil, err
+	}
+	return cities, nil
+}
+
+// maxStateLookups is the most states whose cities are fetched to find the state of each city.
+const maxStateLookups = 20
+
+// fetchCityStates maps the lowercase name of each city in a country to the state it belongs to.
+// The cities of each state are fetched concurrently, for at most maxStateLookups states; truncated reports
+// whether the country has more states than that, so some cities have no state.
+func fetchCityStates(countryName string) (cityStates map[string]string, truncated bool, err error) {
+	states, err := fetchStates(countryName)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(states) > maxStateLookups {
+		states, truncated = states[:maxStateLookups], true
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	cityStates = map[string]string{}
+	limiter := make(chan struct{}, 8) // At most 8 concurrent requests to the API
+
+	for _, state := range states {
+		wg.Add(1)
+		go func(state string) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+
+			cities, err := fetchStateCities(countryName, state)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for _, city := range cities {
+				if _, exists := cityStates[strings.ToLower(city)]; !exists {
+					cityStates[strings.ToLower(city)] = state
+				}
+			}
+		}(state.Name)
+	}
+	wg.Wait()
+
+	if len(cityStates) == 0 && firstErr != nil {
+		return nil, false, firstErr
+	}
+	return cityStates, truncated, nil
+}
+
+// fetchEnrichedCities queries the CountriesNow API for the cities of a country, enriched with population and,
+// if withStates is set, state where available. Failing enrichment is reported as warnings rather than an error.
+func fetchEnrichedCities(countryName string, withStates bool) ([]City, []Warning, error) {
+	names, err := fetchCityNames(countryName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching cities for country: %s: %w", countryName, err)
+	}
+
+	// Enrichment is best effort: the cities are still returned if it fails
+	var warnings []Warning
+	populations, err := fetchCityPopulations(countryName)
+	if err != nil {
+		log.Printf("Error fetching city populations for %s: %v", countryName, err)
+		warnings = append(warnings, NewWarning("cities.population", err))
+	}
+	var cityStates map[string]string
+	if withStates {
+		var truncated bool
+		cityStates, truncated, err = fetchCityStates(countryName)
+		if err != nil {
+			log.Printf("Error fetching city states for %s: %v", countryName, err)
+			warnings = append(warnings, NewWarning("cities.state", err))
+		} else if truncated {
+			warnings = append(warnings, Warning{
+				Resource: "cities.state",
+				Message:  fmt.Sprintf("only the cities of the first %d states have a state", maxStateLookups),
+			})
+		}
+	}
+
+	// Merge population data into the list of cities, matching names case-insensitively
+	cities := make([]City, 0, len(names))
+	byName := make(map[string]int, len(names))
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, exists := byName[key]; exists {
+			continue
+		}
+		byName[key] = len(cities)
+		cities = append(cities, City{Name: name, State: cityStates[key]})
+	}
+	for _, p := range populations {
+		key := strings.ToLower(p.City)
+		i, exists := byName[key]
+		if !exists {
+			byName[key] = len(cities)
+			cities = append(cities, City{Name: p.City, State: cityStates[key]})
+			i = len(cities) - 1
+		}
+		cities[i].Population, cities[i].PopulationYear = p.Population, p.Year
+	}
+
+	return cities, warnings, nil
+}
+
+// FetchCities retrieves the cities of a country, filters and sorts them according to the query and returns
+// the requested page along with the number of cities matching the filters and any enrichment warnings.
+func FetchCities(countryName string, query CityQuery) ([]City, int, []Warning, error) {
+	cities, warnings, err := fetchEnrichedCities(countryName, query.States)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	page, total := query.apply(cities)
+	return page, total, warnings, nil
+}
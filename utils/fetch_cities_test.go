@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFetchEnrichedCitiesStates(t *testing.T) {
+	previousTransport := http.DefaultTransport
+	defer func() {
+		http.DefaultTransport = previousTransport
+		PurgeCache()
+	}()
+
+	// A country with more states than are looked up, each with a city named after it
+	var states []map[string]string
+	var cities []string
+	for i := 1; i <= maxStateLookups+5; i++ {
+		states = append(states, map[string]string{"name": fmt.Sprintf("State %d", i), "state_code": fmt.Sprint(i)})
+		cities = append(cities, fmt.Sprintf("City %d", i))
+	}
+	var mu sync.Mutex
+	stateLookups := 0
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var payload struct {
+			State string `json:"state"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		var data interface{}
+		switch strings.TrimPrefix(r.URL.Path, "/api/v0.1/countries/") {
+		case "cities":
+			data = cities
+		case "population/cities/filter":
+			data = []interface{}{}
+		case "states":
+			data = map[string]interface{}{"states": states}
+		case "state/cities":
+			mu.Lock()
+			stateLookups++
+			mu.Unlock()
+			data = []string{"City " + strings.TrimPrefix(payload.State, "State ")}
+		default:
+			return nil, fmt.Errorf("unexpected request %s", r.URL.Path)
+		}
+		body, _ := json.Marshal(map[string]interface{}{"error": false, "msg": "ok", "data": data})
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(body)))}, nil
+	})
+
+	// Without states, no state is looked up
+	PurgeCache()
+	result, warnings, err := fetchEnrichedCities("Statia", false)
+	if err != nil || len(result) != len(cities) || len(warnings) != 0 {
+		t.Fatalf("fetchEnrichedCities = %d cities, %v, %v", len(result), warnings, err)
+	}
+	if stateLookups != 0 || result[0].State != "" {
+		t.Errorf("%d state lookups and state %q, want none without states", stateLookups, result[0].State)
+	}
+
+	// With states, at most maxStateLookups are looked up and the rest is reported
+	result, warnings, err = fetchEnrichedCities("Statia", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stateLookups != maxStateLookups {
+		t.Errorf("%d state lookups, want %d", stateLookups, maxStateLookups)
+	}
+	if result[0].State != "State 1" || result[len(result)-1].State != "" {
+		t.Errorf("states %q and %q, want the first state only", result[0].State, result[len(result)-1].State)
+	}
+	if len(warnings) != 1 || warnings[0].Resource != "cities.state" {
+		t.Errorf("warnings = %+v, want one for the states left out", warnings)
+	}
+}
@@ -2,211 +2,190 @@ package utils
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"log"
+	"time"
+
+	"country-info-service/restcountries"
 )
 
 // CountryInfoResponse represents the structured response for country information.
 type CountryInfoResponse struct {
-	Name       string            `json:"name"`       
-	Continent  string            `json:"continent"`  
-	Population int               `json:"population"` 
-	Languages  map[string]string `json:"languages"`  
-	Borders    []string          `json:"borders"`   
-	Flag       string            `json:"flag"`       
-	Capital    string            `json:"capital"`   
-	Cities     []string          `json:"cities"`   
+	Name            string                `json:"name"`
+	OfficialName    string                `json:"officialName"`
+	NativeNames     map[string]NativeName `json:"nativeNames"`
+	Continent       string                `json:"continent"`
+	Subregion       string                `json:"subregion"`
+	Population      int                   `json:"population"`
+	Area            float64               `json:"area"`
+	LatLng          []float64             `json:"latlng"`
+	Languages       map[string]string     `json:"languages"`
+	Currencies      map[string]Currency   `json:"currencies"`
+	Borders         []string              `json:"borders"`
+	BorderCountries []CountrySummary      `json:"borderCountries,omitempty"`
+	Landlocked      bool                  `json:"landlocked"`
+	UNMember        bool                  `json:"unMember"`
+	Timezones       []string              `json:"timezones"`
+	CallingCodes    []string              `json:"callingCodes"`
+	TLDs            []string              `json:"tlds"`
+	Demonyms        map[string]Demonym    `json:"demonyms"`
+	CarSide         string                `json:"carSide"`
+	Flag            string                `json:"flag"`
+	CoatOfArms      string                `json:"coatOfArms"`
+	Capital         string                `json:"capital"`
+	Cities          []City                `json:"cities"`
+	CitiesTotal     int                   `json:"citiesTotal"`
+	CitiesNext      string                `json:"citiesNextCursor,omitempty"`
+	Warnings        []Warning             `json:"warnings,omitempty"`
+	Language        string                `json:"-"` // ISO 639-1 code of the language the names are in
+	FetchedAt       time.Time             `json:"-"` // When the country record was fetched from upstream
 }
 
-// FetchCountryInfo queries the REST Countries API and the Cities API to get country details.
-func FetchCountryInfo(countryCode string, limit int) (*CountryInfoResponse, error) {
-	url := fmt.Sprintf("http://129.241.150.113:8080/v3.1/alpha/%s", countryCode)
+// NativeName is the name of a country in one of its own languages.
+type NativeName struct {
+	Official string `json:"official"`
+	Common   string `json:"common"`
+}
+
+// Currency describes a currency used in a country.
+type Currency struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+}
+
+// Demonym holds the female and male demonyms for a country in one language.
+type Demonym struct {
+	F string `json:"f"`
+	M string `json:"m"`
+}
 
-	// Make HTTP request
-	resp, err := http.Get(url)
+// countryURL is the REST Countries endpoint for a single country, followed by its ISO2 or ISO3 code.
+const countryURL = "http://129.241.150.113:8080/v3.1/alpha/"
+
+// fetchCountry retrieves a single country from the REST Countries API by ISO2 or ISO3 code, using the
+// upstream cache. The time the record was fetched from upstream is returned with it.
+func fetchCountry(countryCode string) (restcountries.Country, time.Time, error) {
+	url := countryURL + countryCode
+
+	body, fetchedAt, err := fetchCached(url, func() ([]byte, error) { return fetchBody(url) })
 	if err != nil {
 		log.Printf("Error fetching country data: %v", err)
-		return nil, fmt.Errorf("failed to fetch country data: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP response status
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("REST Countries API returned status: %d", resp.StatusCode)
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return restcountries.Country{}, time.Time{}, fmt.Errorf("failed to fetch country data: %w", err)
 	}
 
 	// Decode JSON response
-	var data []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+	country, err := restcountries.DecodeOne(bytes.NewReader(body))
+	if err != nil {
 		log.Printf("Error decoding country API response: %v", err)
-		return nil, fmt.Errorf("failed to decode country API response: %w", err)
+		return restcountries.Country{}, time.Time{}, fmt.Errorf("failed to decode country API response: %w", err)
 	}
 
-	// Ensure response contains data
-	if len(data) == 0 {
-		log.Printf("No data found for country code: %s", countryCode)
-		return nil, fmt.Errorf("no data found for country code: %s", countryCode)
-	}
+	return *country, fetchedAt, nil
+}
 
-	country := data[0]
+// FetchCountryInfo queries the REST Countries API and the Cities API to get country details.
+// The cities are filtered, sorted and paged according to cityQuery. The country name is given in the first of
+// the preferred languages (language tags, most preferred first) that has a translation, otherwise in English.
+// Capital and language names are only available in English.
+func FetchCountryInfo(countryCode string, cityQuery CityQuery, languages []string) (*CountryInfoResponse, error) {
+	country, fetchedAt, err := fetchCountry(countryCode)
+	if err != nil {
+		return nil, err
+	}
 
-	// Extract country name
-	name, ok := extractString(country, "name", "common")
-	if !ok {
+	// Ensure country name is present
+	name := country.Name.Common
+	if name == "" {
 		log.Printf("Country name not found in API response")
 		return nil, fmt.Errorf("country name not found")
 	}
 
-	// Extract region
-	region, ok := country["region"].(string)
-	if !ok {
+	// Default region and capital when missing
+	region := country.Region
+	if region == "" {
 		region = "Unknown"
 	}
-
-	// Extract borders
-	borders := extractStringArray(country, "borders")
-
-	// Extract languages
-	languages := make(map[string]string)
-	if langs, ok := country["languages"].(map[string]interface{}); ok {
-		for abbr, lang := range langs {
-			if langName, valid := lang.(string); valid {
-				languages[abbr] = langName
-			}
-		}
-	}
-
-	// Extract flag URL
-	flag, _ := extractString(country, "flags", "svg")
-
-	// Extract capital city
 	capital := "N/A"
-	if capList, ok := country["capital"].([]interface{}); ok && len(capList) > 0 {
-		if capStr, valid := capList[0].(string); valid {
-			capital = capStr
-		}
+	if len(country.Capital) > 0 {
+		capital = country.Capital[0]
 	}
 
-	// Extract population
-	population := 0
-	if pop, ok := country["population"].(float64); ok {
-		population = int(pop)
+	// Convert the nested REST Countries records to response types
+	nativeNames := map[string]NativeName{}
+	for lang, native := range country.Name.NativeName {
+		nativeNames[lang] = NativeName(native)
+	}
+	currencies := map[string]Currency{}
+	for code, currency := range country.Currencies {
+		currencies[code] = Currency(currency)
+	}
+	demonyms := map[string]Demonym{}
+	for lang, demonym := range country.Demonyms {
+		demonyms[lang] = Demonym(demonym)
 	}
 
-	// Fetch cities
-	cities, err := FetchCities(name, limit)
+	// Fetch cities. If they are unavailable the rest of the response is still returned,
+	// with cities set to null and a warning explaining why.
+	cities, citiesTotal, warnings, err := FetchCities(name, cityQuery)
 	if err != nil {
 		log.Printf("Error fetching cities: %v", err)
-		cities = []string{"City data not available"}
+		warnings = append(warnings, NewWarning("cities", err))
 	}
 
+	// Localize the names. Cities are looked up by the English name above, as CountriesNow expects.
+	localName, localOfficialName, language := localizedName(country, languages)
+
 	// Construct the response
 	response := CountryInfoResponse{
-		Name:       name,
-		Continent:  region,
-		Population: population,
-		Languages:  languages,
-		Borders:    borders,
-		Flag:       flag,
-		Capital:    capital,
-		Cities:     cities,
+		Name:         localName,
+		OfficialName: localOfficialName,
+		NativeNames:  nativeNames,
+		Continent:    region,
+		Subregion:    country.Subregion,
+		Population:   country.Population,
+		Area:         country.Area,
+		LatLng:       country.LatLng,
+		Languages:    country.Languages,
+		Currencies:   currencies,
+		Borders:      country.Borders,
+		Landlocked:   country.Landlocked,
+		UNMember:     country.UNMember,
+		Timezones:    country.Timezones,
+		CallingCodes: country.CallingCodes(),
+		TLDs:         country.TLD,
+		Demonyms:     demonyms,
+		CarSide:      country.Car.Side,
+		Flag:         country.Flags.SVG,
+		CoatOfArms:   country.CoatOfArms.SVG,
+		Capital:      capital,
+		Cities:       cities,
+		CitiesTotal:  citiesTotal,
+		Warnings:     warnings,
+		Language:     language,
+		FetchedAt:    fetchedAt,
+	}
+
+	// Point to the next page of cities if there is one
+	if next := cityQuery.Offset + len(cities); next < citiesTotal {
+		response.CitiesNext = EncodeCityCursor(next)
+	}
+
+	// Return empty collections rather than null for missing data
+	if response.LatLng == nil {
+		response.LatLng = []float64{}
+	}
+	if response.Languages == nil {
+		response.Languages = map[string]string{}
+	}
+	if response.Borders == nil {
+		response.Borders = []string{}
+	}
+	if response.Timezones == nil {
+		response.Timezones = []string{}
+	}
+	if response.TLDs == nil {
+		response.TLDs = []string{}
 	}
 
 	return &response, nil
 }
-
-// FetchCities queries the Cities API to get a list of cities for a given country.
-func FetchCities(countryName string, limit int) ([]string, error) {
-	url := "http://129.241.150.113:3500/api/v0.1/countries/cities"
-	payload := []byte(fmt.Sprintf(`{"country": "%s"}`, countryName))
-
-	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
-	if err != nil {
-		log.Printf("Error creating cities API request: %v", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error making request to Cities API: %v", err)
-		return nil, fmt.Errorf("failed to fetch cities: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP response status
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Cities API returned status: %d", resp.StatusCode)
-		return nil, fmt.Errorf("cities API returned status %d", resp.StatusCode)
-	}
-
-	// Read response
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading cities API response: %v", err)
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse API response
-	var apiResponse struct {
-		Error  bool     `json:"error"`
-		Cities []string `json:"data"`
-	}
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		log.Printf("Error decoding cities API response: %v", err)
-		return nil, fmt.Errorf("failed to parse cities API response: %w", err)
-	}
-
-	// Handle API errors
-	if apiResponse.Error {
-		log.Printf("Cities API reported an error for country: %s", countryName)
-		return nil, fmt.Errorf("error fetching cities for country: %s", countryName)
-	}
-
-	// Apply limit to cities
-	if len(apiResponse.Cities) > limit {
-		return apiResponse.Cities[:limit], nil
-	}
-
-	return apiResponse.Cities, nil
-}
-
-// Extracts a nested string value from a map.
-func extractString(data map[string]interface{}, keys ...string) (string, bool) {
-	for _, key := range keys {
-		if nestedMap, ok := data[key].(map[string]interface{}); ok {
-			for _, nestedKey := range keys {
-				if value, exists := nestedMap[nestedKey]; exists {
-					if strValue, valid := value.(string); valid {
-						return strValue, true
-					}
-				}
-			}
-		} else if value, exists := data[key]; exists {
-			if strValue, valid := value.(string); valid {
-				return strValue, true
-			}
-		}
-	}
-	return "", false
-}
-
-// Extracts an array of strings from a map.
-func extractStringArray(data map[string]interface{}, key string) []string {
-	result := []string{}
-	if arr, ok := data[key].([]interface{}); ok {
-		for _, item := range arr {
-			if strItem, valid := item.(string); valid {
-				result = append(result, strItem)
-			}
-		}
-	}
-	return result
-}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// CitiesResponse represents one page of the cities of a country, or of a state within it.
+type CitiesResponse struct {
+	Country    string    `json:"country"`
+	State      string    `json:"state,omitempty"`
+	Total      int       `json:"total"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	Cities     []City    `json:"cities"`
+	Warnings   []Warning `json:"warnings,omitempty"`
+}
+
+// StatesResponse represents the states or provinces of a country.
+type StatesResponse struct {
+	Country string  `json:"country"`
+	Count   int     `json:"count"`
+	States  []State `json:"states"`
+}
+
+// FetchCountryStates retrieves the states or provinces of the country with the given ISO2 code.
+func FetchCountryStates(iso2 string) (*StatesResponse, error) {
+	countryName, err := FetchCountryName(iso2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get country name: %w", err)
+	}
+
+	states, err := fetchStates(countryName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get states: %w", err)
+	}
+
+	return &StatesResponse{
+		Country: countryName,
+		Count:   len(states),
+		States:  states,
+	}, nil
+}
+
+// FetchCountryCities retrieves the cities of the country with the given ISO2 code, filtered, sorted and paged
+// according to the query. If state is set (by name or state code), only the cities of that state are included.
+func FetchCountryCities(iso2, state string, query CityQuery) (*CitiesResponse, error) {
+	countryName, err := FetchCountryName(iso2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get country name: %w", err)
+	}
+
+	var (
+		cities   []City
+		warnings []Warning
+	)
+	if state == "" {
+		cities, warnings, err = fetchEnrichedCities(countryName, query.States)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		state, err = resolveState(countryName, state)
+		if err != nil {
+			return nil, err
+		}
+		cities, warnings, err = fetchEnrichedStateCities(countryName, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page, total := query.apply(cities)
+	response := &CitiesResponse{
+		Country:  countryName,
+		State:    state,
+		Total:    total,
+		Cities:   page,
+		Warnings: warnings,
+	}
+	if next := query.Offset + len(page); next < total {
+		response.NextCursor = EncodeCityCursor(next)
+	}
+
+	return response, nil
+}
+
+// resolveState finds the name of a state of a country given its name or state code (case-insensitive).
+func resolveState(countryName, state string) (string, error) {
+	states, err := fetchStates(countryName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get states: %w", err)
+	}
+	for _, s := range states {
+		if strings.EqualFold(s.Name, state) || strings.EqualFold(s.Code, state) {
+			return s.Name, nil
+		}
+	}
+	return "", fmt.Errorf("state not found: %s", state)
+}
+
+// fetchEnrichedStateCities retrieves the cities of a state, with population where available.
+func fetchEnrichedStateCities(countryName, state string) ([]City, []Warning, error) {
+	names, err := fetchStateCities(countryName, state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching cities for state: %s: %w", state, err)
+	}
+
+	// Population is best effort, as for the cities of a country
+	var warnings []Warning
+	populations, err := fetchCityPopulations(countryName)
+	if err != nil {
+		log.Printf("Error fetching city populations for %s: %v", countryName, err)
+		warnings = append(warnings, NewWarning("cities.population", err))
+	}
+	byName := make(map[string]cityPopulation, len(populations))
+	for _, p := range populations {
+		byName[strings.ToLower(p.City)] = p
+	}
+
+	cities := make([]City, 0, len(names))
+	for _, name := range names {
+		p := byName[strings.ToLower(name)]
+		cities = append(cities, City{Name: name, State: state, Population: p.Population, PopulationYear: p.Year})
+	}
+	return cities, warnings, nil
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"country-info-service/rates"
+)
+
+// defaultRateProvider supplies the stand-in rates bundled with the service.
+var defaultRateProvider rates.Provider = rates.Default()
+
+// rateProvider holds the provider set with SetRateProvider, if any.
+var rateProvider atomic.Pointer[rates.Provider]
+
+// SetRateProvider replaces the provider of the exchange rates used for conversions. It may be called while
+// requests are served; conversions already running finish with the previous rates.
+func SetRateProvider(provider rates.Provider) {
+	rateProvider.Store(&provider)
+}
+
+// RateProvider returns the provider of the exchange rates used for conversions. It defaults to the stand-in
+// rates bundled with the service.
+func RateProvider() rates.Provider {
+	if provider := rateProvider.Load(); provider != nil {
+		return *provider
+	}
+	return defaultRateProvider
+}
+
+// CurrencyUsage describes a currency and the countries using it.
+type CurrencyUsage struct {
+	Code      string           `json:"code"`
+	Name      string           `json:"name"`
+	Symbol    string           `json:"symbol"`
+	Countries []CountrySummary `json:"countries"`
+}
+
+// CurrenciesResponse represents all currencies in use, ordered by code.
+type CurrenciesResponse struct {
+	Count      int             `json:"count"`
+	Currencies []CurrencyUsage `json:"currencies"`
+}
+
+// ConversionResponse represents an amount converted between currencies, optionally divided by
+// the population of a country.
+type ConversionResponse struct {
+	Amount     float64 `json:"amount"`
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Rate       float64 `json:"rate"`
+	Result     float64 `json:"result"`
+	Country    string  `json:"country,omitempty"`
+	Population int     `json:"population,omitempty"`
+	PerCapita  float64 `json:"perCapita,omitempty"`
+	RateSource string  `json:"rateSource"`
+}
+
+// FetchCurrencies lists every currency used by a country in the REST Countries dataset,
+// with the countries using it.
+func FetchCurrencies() (*CurrenciesResponse, error) {
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	byCode := map[string]*CurrencyUsage{}
+	for _, country := range countries {
+		for code, currency := range country.Currencies {
+			usage, ok := byCode[code]
+			if !ok {
+				usage = &CurrencyUsage{Code: code, Name: currency.Name, Symbol: currency.Symbol}
+				byCode[code] = usage
+			}
+			usage.Countries = append(usage.Countries, newCountrySummary(country, 0))
+		}
+	}
+
+	currencies := make([]CurrencyUsage, 0, len(byCode))
+	for _, usage := range byCode {
+		sort.Slice(usage.Countries, func(i, j int) bool { return usage.Countries[i].Name < usage.Countries[j].Name })
+		currencies = append(currencies, *usage)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+
+	return &CurrenciesResponse{Count: len(currencies), Currencies: currencies}, nil
+}
+
+// FetchCurrency describes a single currency, given by ISO 4217 code, and the countries using it.
+func FetchCurrency(code string) (*CurrencyUsage, error) {
+	currencies, err := FetchCurrencies()
+	if err != nil {
+		return nil, err
+	}
+	for _, currency := range currencies.Currencies {
+		if strings.EqualFold(currency.Code, code) {
+			return &currency, nil
+		}
+	}
+	return nil, fmt.Errorf("currency not found: %s", code)
+}
+
+// Convert converts amount from one currency to another using RateProvider. If perCapitaCountry is set
+// (ISO2 or ISO3 code), the result is also divided by the population of that country, for figures such as
+// GDP per capita; to then defaults to the currency of that country.
+func Convert(amount float64, from, to, perCapitaCountry string) (*ConversionResponse, error) {
+	provider := RateProvider()
+	response := &ConversionResponse{
+		Amount:     amount,
+		From:       strings.ToUpper(from),
+		To:         strings.ToUpper(to),
+		RateSource: provider.Source(),
+	}
+
+	if perCapitaCountry != "" {
+		index, err := countriesByISO3()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get countries: %w", err)
+		}
+		country, ok := lookupCountry(index, perCapitaCountry)
+		if !ok {
+			return nil, fmt.Errorf("country not found for code: %s", perCapitaCountry)
+		}
+		if country.Population <= 0 {
+			return nil, fmt.Errorf("no population data for country: %s", country.CCA2)
+		}
+		response.Country, response.Population = country.CCA2, country.Population
+
+		// Default to the country's own currency, picking the first code if it has several
+		if response.To == "" {
+			codes := make([]string, 0, len(country.Currencies))
+			for code := range country.Currencies {
+				codes = append(codes, code)
+			}
+			if len(codes) == 0 {
+				return nil, fmt.Errorf("no currency for country: %s", country.CCA2)
+			}
+			sort.Strings(codes)
+			response.To = codes[0]
+		}
+	}
+	if response.To == "" {
+		return nil, fmt.Errorf("missing target currency")
+	}
+
+	rate, err := provider.Rate(response.From, response.To)
+	if err != nil {
+		return nil, err
+	}
+	response.Rate = rate
+	response.Result = roundTo(amount*rate, 4)
+	if response.Population > 0 {
+		response.PerCapita = roundTo(amount*rate/float64(response.Population), 4)
+	}
+
+	return response, nil
+}
+
+// roundTo rounds value to the given number of decimals.
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
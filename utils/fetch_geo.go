@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	"country-info-service/geo"
+	"country-info-service/restcountries"
+)
+
+// Points a country can be located by in geospatial queries.
+const (
+	GeoPointCentroid = "centroid" // The country's latlng, roughly its center
+	GeoPointCapital  = "capital"  // The coordinates of the capital
+)
+
+// CountryLocation is a country with the coordinates used to locate it.
+type CountryLocation struct {
+	CountrySummary
+	LatLng        []float64 `json:"latlng"`
+	CapitalLatLng []float64 `json:"capitalLatLng,omitempty"`
+	Area          float64   `json:"area"`
+}
+
+// NearbyCountry is a country within the radius of a nearby query.
+type NearbyCountry struct {
+	CountryLocation
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// NearbyResponse represents the countries within a radius of a point, nearest first.
+type NearbyResponse struct {
+	Lat      float64         `json:"lat"`
+	Lng      float64         `json:"lng"`
+	RadiusKm float64         `json:"radiusKm"`
+	Point    string          `json:"point"`
+	Count    int             `json:"count"`
+	Results  []NearbyCountry `json:"results"`
+}
+
+// BoundingBoxResponse represents the countries located inside a bounding box, ordered by name.
+type BoundingBoxResponse struct {
+	BoundingBox geo.BoundingBox   `json:"bbox"`
+	Point       string            `json:"point"`
+	Count       int               `json:"count"`
+	Countries   []CountryLocation `json:"countries"`
+}
+
+// CapitalLocation is the capital of a country and its coordinates.
+type CapitalLocation struct {
+	Country string    `json:"country"`
+	Name    string    `json:"name"`
+	Capital string    `json:"capital"`
+	LatLng  []float64 `json:"latlng"`
+}
+
+// DistanceResponse represents the great-circle distance between the capitals of two countries.
+type DistanceResponse struct {
+	From       CapitalLocation `json:"from"`
+	To         CapitalLocation `json:"to"`
+	DistanceKm float64         `json:"distanceKm"`
+}
+
+// newCountryLocation builds a CountryLocation from a REST Countries record.
+func newCountryLocation(country restcountries.Country) CountryLocation {
+	return CountryLocation{
+		CountrySummary: newCountrySummary(country, 0),
+		LatLng:         country.LatLng,
+		CapitalLatLng:  country.CapitalInfo.LatLng,
+		Area:           country.Area,
+	}
+}
+
+// countryPoint returns the point a country is located by. Countries without coordinates for it are reported as not found.
+func countryPoint(country restcountries.Country, point string) (geo.Point, bool) {
+	if point == GeoPointCapital {
+		return geo.PointFromLatLng(country.CapitalInfo.LatLng)
+	}
+	return geo.PointFromLatLng(country.LatLng)
+}
+
+// FetchNearby returns up to limit countries located within radiusKm kilometers of the center, nearest first.
+// Countries are located by their centroid or their capital, as chosen by point.
+func FetchNearby(center geo.Point, radiusKm float64, point string, limit int) (*NearbyResponse, error) {
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	results := []NearbyCountry{}
+	for _, country := range countries {
+		location, ok := countryPoint(country, point)
+		if !ok {
+			continue
+		}
+		if distance := geo.Distance(center, location); distance <= radiusKm {
+			results = append(results, NearbyCountry{
+				CountryLocation: newCountryLocation(country),
+				DistanceKm:      roundTo(distance, 1),
+			})
+		}
+	}
+
+	// Nearest first, breaking ties by name
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceKm != results[j].DistanceKm {
+			return results[i].DistanceKm < results[j].DistanceKm
+		}
+		return results[i].Name < results[j].Name
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &NearbyResponse{
+		Lat:      center.Lat,
+		Lng:      center.Lng,
+		RadiusKm: radiusKm,
+		Point:    point,
+		Count:    len(results),
+		Results:  results,
+	}, nil
+}
+
+// FetchBoundingBox returns the countries located inside the bounding box, by their centroid or capital.
+func FetchBoundingBox(box geo.BoundingBox, point string) (*BoundingBoxResponse, error) {
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	locations := []CountryLocation{}
+	for _, country := range countries {
+		if location, ok := countryPoint(country, point); ok && box.Contains(location) {
+			locations = append(locations, newCountryLocation(country))
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Name < locations[j].Name })
+
+	return &BoundingBoxResponse{
+		BoundingBox: box,
+		Point:       point,
+		Count:       len(locations),
+		Countries:   locations,
+	}, nil
+}
+
+// FetchCapitalDistance returns the great-circle distance between the capitals of two countries,
+// given by ISO2 or ISO3 code.
+func FetchCapitalDistance(from, to string) (*DistanceResponse, error) {
+	index, err := countriesByISO3()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	var capitals [2]CapitalLocation
+	var points [2]geo.Point
+	for i, code := range []string{from, to} {
+		country, ok := lookupCountry(index, code)
+		if !ok {
+			return nil, fmt.Errorf("country not found for code: %s", code)
+		}
+		point, ok := countryPoint(country, GeoPointCapital)
+		if !ok || len(country.Capital) == 0 {
+			return nil, fmt.Errorf("no capital coordinates for country: %s", country.CCA2)
+		}
+		capitals[i] = CapitalLocation{
+			Country: country.CCA2,
+			Name:    country.Name.Common,
+			Capital: country.Capital[0],
+			LatLng:  country.CapitalInfo.LatLng,
+		}
+		points[i] = point
+	}
+
+	return &DistanceResponse{
+		From:       capitals[0],
+		To:         capitals[1],
+		DistanceKm: roundTo(geo.Distance(points[0], points[1]), 1),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LanguageUsage describes a language and the countries where it is spoken.
+type LanguageUsage struct {
+	Code               string           `json:"code"`
+	Name               string           `json:"name"`
+	CountryCount       int              `json:"countryCount"`
+	CombinedPopulation int              `json:"combinedPopulation"` // Total population of the countries speaking it
+	Countries          []CountrySummary `json:"countries"`
+}
+
+// LanguagesResponse represents all languages spoken in any country, ordered by name.
+type LanguagesResponse struct {
+	Count     int             `json:"count"`
+	Languages []LanguageUsage `json:"languages"`
+}
+
+// FetchLanguages lists every language in the REST Countries dataset with the countries speaking it.
+func FetchLanguages() (*LanguagesResponse, error) {
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	byCode := map[string]*LanguageUsage{}
+	for _, country := range countries {
+		for code, name := range country.Languages {
+			usage, ok := byCode[code]
+			if !ok {
+				usage = &LanguageUsage{Code: code, Name: name}
+				byCode[code] = usage
+			}
+			usage.Countries = append(usage.Countries, newCountrySummary(country, 0))
+			usage.CountryCount++
+			usage.CombinedPopulation += country.Population
+		}
+	}
+
+	languages := make([]LanguageUsage, 0, len(byCode))
+	for _, usage := range byCode {
+		sort.Slice(usage.Countries, func(i, j int) bool { return usage.Countries[i].Population > usage.Countries[j].Population })
+		languages = append(languages, *usage)
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i].Name < languages[j].Name })
+
+	return &LanguagesResponse{Count: len(languages), Languages: languages}, nil
+}
+
+// FetchLanguage describes a single language, given by its ISO 639-3 code (or ISO 639-1 code
+// where known), and the countries speaking it.
+func FetchLanguage(code string) (*LanguageUsage, error) {
+	languages, err := FetchLanguages()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := languageTag(code)
+	for _, language := range languages.Languages {
+		if strings.EqualFold(language.Code, wanted) {
+			return &language, nil
+		}
+	}
+	return nil, fmt.Errorf("language not found: %s", code)
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"country-info-service/bordergraph"
+	"country-info-service/restcountries"
+)
+
+// CountrySummary is a short description of a country used in lists of related countries.
+type CountrySummary struct {
+	Name       string `json:"name"`
+	ISO2       string `json:"iso2"`
+	ISO3       string `json:"iso3"`
+	Capital    string `json:"capital"`
+	Flag       string `json:"flag"`
+	Population int    `json:"population"`
+	Depth      int    `json:"depth,omitempty"`
+}
+
+// NeighborsResponse represents the countries reachable over land borders from a country.
+type NeighborsResponse struct {
+	Country   string           `json:"country"`
+	Depth     int              `json:"depth"`
+	Neighbors []CountrySummary `json:"neighbors"`
+}
+
+// newCountrySummary builds a CountrySummary from a REST Countries record.
+func newCountrySummary(country restcountries.Country, depth int) CountrySummary {
+	capital := "N/A"
+	if len(country.Capital) > 0 {
+		capital = country.Capital[0]
+	}
+	return CountrySummary{
+		Name:       country.Name.Common,
+		ISO2:       country.CCA2,
+		ISO3:       country.CCA3,
+		Capital:    capital,
+		Flag:       country.Flags.SVG,
+		Population: country.Population,
+		Depth:      depth,
+	}
+}
+
+// countriesByISO3 indexes the cached REST Countries dataset by ISO3 code.
+func countriesByISO3() (map[string]restcountries.Country, error) {
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, err
+	}
+	return indexByISO3(countries), nil
+}
+
+// indexByISO3 maps each country's ISO3 code to its record.
+func indexByISO3(countries []restcountries.Country) map[string]restcountries.Country {
+	index := make(map[string]restcountries.Country, len(countries))
+	for _, c := range countries {
+		index[c.CCA3] = c
+	}
+	return index
+}
+
+// lookupCountry finds a country in the index by its ISO2 or ISO3 code.
+func lookupCountry(index map[string]restcountries.Country, code string) (restcountries.Country, bool) {
+	code = strings.ToUpper(code)
+	if country, ok := index[code]; ok {
+		return country, true
+	}
+	for _, country := range index {
+		if country.CCA2 == code {
+			return country, true
+		}
+	}
+	return restcountries.Country{}, false
+}
+
+// borderGraph builds the land border graph from the cached REST Countries dataset,
+// and returns it with the dataset indexed by ISO3 code.
+func borderGraph() (*bordergraph.Graph, map[string]restcountries.Country, error) {
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+	return bordergraph.New(countries), indexByISO3(countries), nil
+}
+
+// FetchBorderCountries resolves a list of ISO3 border codes to country summaries.
+// Codes that are not found in the dataset are skipped.
+func FetchBorderCountries(codes []string) ([]CountrySummary, error) {
+	index, err := countriesByISO3()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	neighbors := []CountrySummary{}
+	for _, code := range codes {
+		if country, ok := index[strings.ToUpper(code)]; ok {
+			neighbors = append(neighbors, newCountrySummary(country, 0))
+		}
+	}
+	return neighbors, nil
+}
+
+// FetchNeighbors returns every country reachable over land borders from the country with the given
+// ISO2 code in at most depth border crossings, ordered by depth. Depth 1 gives the direct neighbors.
+func FetchNeighbors(iso2 string, depth int) (*NeighborsResponse, error) {
+	graph, index, err := borderGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	start, ok := lookupCountry(index, iso2)
+	if !ok {
+		return nil, fmt.Errorf("country not found for ISO2 code: %s", iso2)
+	}
+
+	neighbors := []CountrySummary{}
+	for level, codes := range graph.Levels(start.CCA3, depth) {
+		for _, code := range codes {
+			neighbors = append(neighbors, newCountrySummary(index[code], level+1))
+		}
+	}
+
+	return &NeighborsResponse{
+		Country:   start.CCA2,
+		Depth:     depth,
+		Neighbors: neighbors,
+	}, nil
+}
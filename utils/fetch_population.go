@@ -1,12 +1,10 @@
 package utils
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
+	"time"
 )
 
 // The response structure for population data.
@@ -16,6 +14,7 @@ type PopulationResponse struct {
 		Year  int `json:"year"`
 		Value int `json:"value"`
 	} `json:"values"`
+	FetchedAt time.Time `json:"-"` // When the population data was last fetched from upstream
 }
 
 // The response structure for population API data.
@@ -33,52 +32,28 @@ type ApiResponse struct {
 	} `json:"data"`
 }
 
-// Response structure to hold the common name of a country.
-type CountryNameResponse []struct {
-	Name struct {
-		Common string `json:"common"`
-	} `json:"name"`
-}
-
 // FetchCountryName retrieves the common name of a country using its ISO2 code.
 func FetchCountryName(iso2 string) (string, error) {
-	url := fmt.Sprintf("http://129.241.150.113:8080/v3.1/alpha/%s", iso2)
+	name, _, err := fetchCountryName(iso2)
+	return name, err
+}
 
-	// Fetch country name from the API
-	resp, err := http.Get(url)
+// fetchCountryName retrieves the common name of a country using its ISO2 code, and the time the
+// country record was fetched from upstream.
+func fetchCountryName(iso2 string) (string, time.Time, error) {
+	country, fetchedAt, err := fetchCountry(iso2)
 	if err != nil {
 		log.Printf("Error fetching country name: %v", err)
-		return "", fmt.Errorf("failed to fetch country name: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check if the API returned a valid response
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Country API returned status %d", resp.StatusCode)
-		return "", fmt.Errorf("country API returned status %d", resp.StatusCode)
+		return "", time.Time{}, fmt.Errorf("failed to fetch country name: %w", err)
 	}
 
-	// Read the response body
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading country API response: %v", err)
-		return "", fmt.Errorf("failed to read country API response: %w", err)
-	}
-
-	// Decode the response body
-	var countryData CountryNameResponse
-	if err := json.Unmarshal(body, &countryData); err != nil {
-		log.Printf("Error decoding country API response: %v", err)
-		return "", fmt.Errorf("failed to decode country API response: %w", err)
-	}
-
-	// Ensure response is not empty
-	if len(countryData) == 0 || countryData[0].Name.Common == "" {
+	// Ensure the name is not empty
+	if country.Name.Common == "" {
 		log.Printf("Invalid country name received for ISO2 code: %s", iso2)
-		return "", fmt.Errorf("invalid country name received for ISO2 code: %s", iso2)
+		return "", time.Time{}, fmt.Errorf("invalid country name received for ISO2 code: %s", iso2)
 	}
 
-	return countryData[0].Name.Common, nil
+	return country.Name.Common, fetchedAt, nil
 }
 
 // FetchPopulationData retrieves population data for a country within a given year range.
@@ -89,7 +64,7 @@ func FetchPopulationData(iso2 string, startYear, endYear int) (*PopulationRespon
 	}
 
 	// Fetch country name
-	countryName, err := FetchCountryName(iso2)
+	countryName, nameFetchedAt, err := fetchCountryName(iso2)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get country name: %w", err)
 	}
@@ -108,27 +83,15 @@ func FetchPopulationData(iso2 string, startYear, endYear int) (*PopulationRespon
 		return nil, fmt.Errorf("failed to create JSON request: %w", err)
 	}
 
-	// Send POST request to the population API
+	// Send POST request to the population API, reusing a recent response if there is one
 	populationAPI := "http://129.241.150.113:3500/api/v0.1/countries/population"
-	resp, err := http.Post(populationAPI, "application/json", bytes.NewBuffer(jsonData))
+	body, fetchedAt, err := fetchCached(populationAPI+" "+string(jsonData), func() ([]byte, error) {
+		return postBody(populationAPI, apiRequest)
+	})
 	if err != nil {
 		log.Printf("Error sending request to Population API: %v", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// Check API response status
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Population API returned status: %d", resp.StatusCode)
-		return nil, fmt.Errorf("population API returned status %d", resp.StatusCode)
-	}
-
-	// Read response body
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading Population API response: %v", err)
-		return nil, fmt.Errorf("failed to read Population API response: %w", err)
-	}
 
 	// Decode API response
 	var apiResponse ApiResponse
@@ -170,9 +133,15 @@ func FetchPopulationData(iso2 string, startYear, endYear int) (*PopulationRespon
 		mean = total / count
 	}
 
+	// The data is as recent as the latest of its two upstream responses
+	if nameFetchedAt.After(fetchedAt) {
+		fetchedAt = nameFetchedAt
+	}
+
 	// Return the response
 	return &PopulationResponse{
-		Mean:   mean,
-		Values: filteredCounts,
+		Mean:      mean,
+		Values:    filteredCounts,
+		FetchedAt: fetchedAt,
 	}, nil
 }
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Supported ranking criteria.
+const (
+	RankByPopulation = "population"
+	RankByGrowth     = "growth"
+	RankByDensity    = "density"
+)
+
+// RankingQuery describes which countries to rank, by what, and which page of the result to return.
+type RankingQuery struct {
+	By        string // One of RankByPopulation, RankByGrowth or RankByDensity
+	Region    string // Optional region or subregion filter (case-insensitive)
+	StartYear int    // First year of the growth range (0 = earliest available)
+	EndYear   int    // Last year of the growth range (0 = latest available)
+	Ascending bool   // Rank smallest first instead of largest first
+	Offset    int    // Number of ranked entries to skip
+	Limit     int    // Maximum number of entries to return
+}
+
+// RankingEntry is a single ranked country.
+type RankingEntry struct {
+	Rank       int     `json:"rank"`
+	Name       string  `json:"name"`
+	ISO2       string  `json:"iso2"`
+	ISO3       string  `json:"iso3"`
+	Region     string  `json:"region"`
+	Value      float64 `json:"value"`
+	Population int     `json:"population"`
+	StartYear  int     `json:"startYear,omitempty"`
+	EndYear    int     `json:"endYear,omitempty"`
+}
+
+// RankingsResponse represents one page of a country ranking.
+type RankingsResponse struct {
+	By      string         `json:"by"`
+	Region  string         `json:"region,omitempty"`
+	Total   int            `json:"total"`
+	Offset  int            `json:"offset"`
+	Limit   int            `json:"limit"`
+	Results []RankingEntry `json:"results"`
+}
+
+// FetchRankings ranks all countries (or those in a region) according to the given query.
+// Population and density are based on REST Countries data, growth on the CountriesNow population series.
+func FetchRankings(query RankingQuery) (*RankingsResponse, error) {
+	if query.StartYear > query.EndYear && query.EndYear != 0 {
+		return nil, fmt.Errorf("invalid year range: startYear (%d) cannot be greater than endYear (%d)", query.StartYear, query.EndYear)
+	}
+
+	countries, err := fetchAllCountries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	var series map[string]populationSeries
+	if query.By == RankByGrowth {
+		series, err = fetchAllPopulationSeries()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get population series: %w", err)
+		}
+	}
+
+	entries := []RankingEntry{}
+	for _, c := range countries {
+		if query.Region != "" && !strings.EqualFold(c.Region, query.Region) && !strings.EqualFold(c.Subregion, query.Region) {
+			continue
+		}
+
+		entry := RankingEntry{
+			Name:       c.Name.Common,
+			ISO2:       c.CCA2,
+			ISO3:       c.CCA3,
+			Region:     c.Region,
+			Population: c.Population,
+		}
+
+		switch query.By {
+		case RankByPopulation:
+			entry.Value = float64(c.Population)
+		case RankByDensity:
+			if c.Area <= 0 {
+				continue
+			}
+			entry.Value = float64(c.Population) / c.Area
+		case RankByGrowth:
+			s, ok := series[c.CCA3]
+			if !ok {
+				continue
+			}
+			growth, start, end, ok := populationGrowth(s, query.StartYear, query.EndYear)
+			if !ok {
+				continue
+			}
+			entry.Value, entry.StartYear, entry.EndYear = growth, start, end
+		default:
+			return nil, fmt.Errorf("unknown ranking criterion: %s", query.By)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no countries found for region: %s", query.Region)
+	}
+
+	// Sort by value, falling back to name so equal values keep a stable order
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Value == entries[j].Value {
+			return entries[i].Name < entries[j].Name
+		}
+		if query.Ascending {
+			return entries[i].Value < entries[j].Value
+		}
+		return entries[i].Value > entries[j].Value
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	// Apply pagination
+	total := len(entries)
+	start := query.Offset
+	if start > total {
+		start = total
+	}
+	end := start + query.Limit
+	if end > total {
+		end = total
+	}
+
+	return &RankingsResponse{
+		By:      query.By,
+		Region:  query.Region,
+		Total:   total,
+		Offset:  query.Offset,
+		Limit:   query.Limit,
+		Results: entries[start:end],
+	}, nil
+}
+
+// populationGrowth returns the relative population growth in percent between the first and last
+// counts of the series within the year range, along with the years actually used.
+func populationGrowth(s populationSeries, startYear, endYear int) (float64, int, int, bool) {
+	first, last := -1, -1
+	for i, entry := range s.PopulationCounts {
+		if (startYear != 0 && entry.Year < startYear) || (endYear != 0 && entry.Year > endYear) {
+			continue
+		}
+		if first == -1 || entry.Year < s.PopulationCounts[first].Year {
+			first = i
+		}
+		if last == -1 || entry.Year > s.PopulationCounts[last].Year {
+			last = i
+		}
+	}
+	if first == -1 || first == last || s.PopulationCounts[first].Value == 0 {
+		return 0, 0, 0, false
+	}
+
+	from, to := s.PopulationCounts[first], s.PopulationCounts[last]
+	growth := float64(to.Value-from.Value) / float64(from.Value) * 100
+
+	return growth, from.Year, to.Year, true
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// SearchResult is a country matching a search query.
+type SearchResult struct {
+	CountrySummary
+	MatchedName string  `json:"matchedName"`
+	MatchType   string  `json:"matchType"`
+	Score       float64 `json:"score"`
+}
+
+// SearchResponse represents the countries matching a search query, best match first.
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Count   int            `json:"count"`
+	Results []SearchResult `json:"results"`
+}
+
+// FetchSearch searches the common, official, native, alternative and translated names of all countries
+// for the query, tolerating typos, and returns up to limit matches. The search index is built once per fetch
+// of the country dataset.
+func FetchSearch(query string, limit int) (*SearchResponse, error) {
+	dataset, err := fetchCountriesDataset()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+	index := indexByISO3(dataset.countries)
+
+	results := []SearchResult{}
+	for _, match := range dataset.search().Search(query, limit) {
+		results = append(results, SearchResult{
+			CountrySummary: newCountrySummary(index[match.Code], 0),
+			MatchedName:    match.Name,
+			MatchType:      match.Kind,
+			Score:          math.Round(match.Score*1000) / 1000,
+		})
+	}
+
+	return &SearchResponse{
+		Query:   query,
+		Count:   len(results),
+		Results: results,
+	}, nil
+}
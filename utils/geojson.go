@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"country-info-service/geo"
+)
+
+// defaultBorders are the simplified border polygons bundled with the service.
+var defaultBorders = geo.DefaultBorders()
+
+// borders holds the border polygons set with SetBorders, if any.
+var borders atomic.Pointer[geo.Borders]
+
+// SetBorders replaces the country border polygons used for GeoJSON output, e.g. with a more detailed dataset
+// than the bundled one. It may be called while requests are served.
+func SetBorders(b *geo.Borders) {
+	borders.Store(b)
+}
+
+// Borders returns the country border polygons used for GeoJSON output.
+func Borders() *geo.Borders {
+	if b := borders.Load(); b != nil {
+		return b
+	}
+	return defaultBorders
+}
+
+// FeatureSource is a country to include in a GeoJSON FeatureCollection, and the properties of its feature.
+type FeatureSource struct {
+	Code       string // ISO2 or ISO3 code of the country
+	Properties interface{}
+}
+
+// FetchFeatureCollection builds a GeoJSON FeatureCollection with a feature per source. Each feature is a point
+// at the country's centroid or capital, as chosen by point, or its border polygon if withBorders is set and the
+// borders dataset has one. Countries without known coordinates get a null geometry.
+func FetchFeatureCollection(sources []FeatureSource, point string, withBorders bool) (*geo.FeatureCollection, error) {
+	polygons := Borders()
+	index, err := countriesByISO3()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get countries: %w", err)
+	}
+
+	features := make([]geo.Feature, 0, len(sources))
+	for _, source := range sources {
+		country, ok := lookupCountry(index, source.Code)
+		if !ok {
+			features = append(features, geo.NewFeature(source.Code, nil, source.Properties))
+			continue
+		}
+
+		var geometry []byte
+		if withBorders {
+			geometry, _ = polygons.Geometry(country.CCA3, country.CCA2)
+		}
+		if geometry == nil {
+			if location, ok := countryPoint(country, point); ok {
+				geometry = location.Geometry()
+			}
+		}
+		features = append(features, geo.NewFeature(country.CCA2, geometry, source.Properties))
+	}
+
+	return geo.NewFeatureCollection(features), nil
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strings"
+
+	"country-info-service/restcountries"
+)
+
+// DefaultLanguage is the language used when none of the requested languages are available.
+const DefaultLanguage = "en"
+
+// languageCodes maps ISO 639-1 language codes to the ISO 639-3 codes REST Countries uses for
+// translations and native names.
+var languageCodes = map[string]string{
+	"ar": "ara", "bg": "bul", "br": "bre", "cs": "ces", "cy": "cym", "da": "dan", "de": "deu", "el": "ell",
+	"en": "eng", "es": "spa", "et": "est", "fa": "per", "fi": "fin", "fr": "fra", "ga": "gle", "he": "heb",
+	"hi": "hin", "hr": "hrv", "hu": "hun", "id": "ind", "is": "isl", "it": "ita", "ja": "jpn", "ko": "kor",
+	"lt": "lit", "lv": "lav", "nb": "nob", "nl": "nld", "nn": "nno", "no": "nob", "pl": "pol", "pt": "por",
+	"ro": "ron", "ru": "rus", "sk": "slk", "sl": "slv", "sr": "srp", "sv": "swe", "th": "tha", "tr": "tur",
+	"uk": "ukr", "ur": "urd", "vi": "vie", "zh": "zho",
+}
+
+// languageTag returns the ISO 639-3 code for a language tag such as "de", "de-AT" or "deu",
+// or "" if the language is unknown.
+func languageTag(tag string) string {
+	primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+	if len(primary) == 3 {
+		return primary
+	}
+	return languageCodes[primary]
+}
+
+// contentLanguage returns the ISO 639-1 code for an ISO 639-3 code, falling back to the code itself.
+func contentLanguage(code string) string {
+	for short, long := range languageCodes {
+		if long == code && short != "no" {
+			return short
+		}
+	}
+	return code
+}
+
+// localizedName returns the common and official name of a country in the first of the preferred languages
+// (language tags, most preferred first) that REST Countries has a translation or native name for, along with
+// the ISO 639-1 code of the language used. If none is available the English names are returned.
+func localizedName(country restcountries.Country, preferred []string) (string, string, string) {
+	for _, tag := range preferred {
+		code := languageTag(tag)
+		if code == "" {
+			continue
+		}
+		if code == "eng" {
+			break
+		}
+		if translation, ok := country.Translations[code]; ok && translation.Common != "" {
+			return translation.Common, translation.Official, contentLanguage(code)
+		}
+		if native, ok := country.Name.NativeName[code]; ok && native.Common != "" {
+			return native.Common, native.Official, contentLanguage(code)
+		}
+	}
+	return country.Name.Common, country.Name.Official, DefaultLanguage
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WarmUpConfig configures the startup warm-up.
+type WarmUpConfig struct {
+	// PopulationTopN is the number of most populous countries whose population series are prefetched.
+	// 0 only prefetches the country dataset.
+	PopulationTopN int
+	// Concurrency is the most upstream requests made at once.
+	Concurrency int
+}
+
+// WarmUpProgress reports the progress of the startup warm-up.
+type WarmUpProgress struct {
+	State      string     `json:"state"` // "running" or "done"
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Total      int        `json:"total"`     // Resources to prefetch; known once the country dataset is fetched
+	Completed  int        `json:"completed"` // Resources prefetched, including failures
+	Failed     int        `json:"failed"`
+	Errors     []string   `json:"errors,omitempty"` // The resources that failed, with a generic reason
+}
+
+// warmUp holds the progress of the warm-up, if it was started.
+var warmUp = struct {
+	sync.Mutex
+	progress *WarmUpProgress
+}{}
+
+// WarmUpStatus returns a copy of the warm-up progress, or nil if no warm-up was started.
+func WarmUpStatus() *WarmUpProgress {
+	warmUp.Lock()
+	defer warmUp.Unlock()
+
+	if warmUp.progress == nil {
+		return nil
+	}
+	progress := *warmUp.progress
+	progress.Errors = append([]string(nil), warmUp.progress.Errors...)
+	return &progress
+}
+
+// WarmUp fills the upstream cache so that the first requests after a start do not wait for the upstream APIs.
+// It prefetches the REST Countries dataset, then the population series of the most populous countries (with
+// their country records), at most config.Concurrency at a time. Progress is logged and reported by
+// WarmUpStatus. WarmUp returns when it is done; run it in its own goroutine.
+func WarmUp(config WarmUpConfig) {
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
+
+	warmUp.Lock()
+	warmUp.progress = &WarmUpProgress{State: "running", StartedAt: time.Now().UTC(), Total: 1}
+	warmUp.Unlock()
+	log.Printf("Warm-up: prefetching the country dataset")
+
+	countries, err := fetchAllCountries()
+	recordWarmUp("country dataset", err)
+	if err == nil && config.PopulationTopN > 0 {
+		sort.Slice(countries, func(i, j int) bool { return countries[i].Population > countries[j].Population })
+		if len(countries) > config.PopulationTopN {
+			countries = countries[:config.PopulationTopN]
+		}
+
+		warmUp.Lock()
+		warmUp.progress.Total += len(countries)
+		warmUp.Unlock()
+		log.Printf("Warm-up: prefetching the population of the %d most populous countries", len(countries))
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, config.Concurrency)
+		for _, country := range countries {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(iso2, name string) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				_, err := FetchPopulationData(iso2, 0, 0)
+				recordWarmUp("population of "+name, err)
+			}(country.CCA2, country.Name.Common)
+		}
+		wg.Wait()
+	}
+
+	warmUp.Lock()
+	now := time.Now().UTC()
+	warmUp.progress.State, warmUp.progress.FinishedAt = "done", &now
+	progress := *warmUp.progress
+	warmUp.Unlock()
+	log.Printf("Warm-up: done in %v, %d of %d resources prefetched", now.Sub(progress.StartedAt).Round(time.Millisecond),
+		progress.Completed-progress.Failed, progress.Total)
+}
+
+// recordWarmUp counts a prefetched resource, and logs it. The progress only reports a generic reason for a
+// failure, since it is shown on the public status endpoint; the error itself, which may name upstream hosts,
+// is only logged.
+func recordWarmUp(resource string, err error) {
+	warmUp.Lock()
+	defer warmUp.Unlock()
+
+	progress := warmUp.progress
+	progress.Completed++
+	if err != nil {
+		progress.Failed++
+		progress.Errors = append(progress.Errors, resource+": "+failureReason(err))
+		log.Printf("Warm-up: failed to prefetch %s (%d/%d): %v", resource, progress.Completed, progress.Total, err)
+		return
+	}
+	log.Printf("Warm-up: prefetched %s (%d/%d)", resource, progress.Completed, progress.Total)
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubTransport answers the upstream requests of the warm-up from the sample dataset of the restcountries
+// package. Every response is delayed a little, so concurrent requests overlap, and the most requests in flight
+// at once is recorded. The population of Antarctica fails with a connection error.
+type stubTransport struct {
+	countries []map[string]interface{}
+	all       []byte
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	states   []string // The warm-up state seen by each request
+}
+
+func newStubTransport(t *testing.T) *stubTransport {
+	t.Helper()
+	all, err := os.ReadFile("../restcountries/testdata/sample_all.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubTransport{all: all}
+	if err := json.Unmarshal(all, &stub.countries); err != nil {
+		t.Fatal(err)
+	}
+	return stub
+}
+
+func (s *stubTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	if status := WarmUpStatus(); status != nil {
+		s.states = append(s.states, status.State)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	respond := func(body string) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	switch {
+	case r.URL.Path == "/v3.1/all":
+		return respond(string(s.all))
+	case strings.HasPrefix(r.URL.Path, "/v3.1/alpha/"):
+		code := strings.ToUpper(strings.TrimPrefix(r.URL.Path, "/v3.1/alpha/"))
+		for _, country := range s.countries {
+			if country["cca2"] == code {
+				body, _ := json.Marshal([]interface{}{country})
+				return respond(string(body))
+			}
+		}
+	case r.URL.Path == "/api/v0.1/countries/population":
+		var payload struct {
+			Country string `json:"country"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload.Country == "Antarctica" {
+			return nil, errors.New("connection refused")
+		}
+		return respond(`{"error":false,"msg":"population","data":{"country":"` + payload.Country + `","populationCounts":[{"year":2018,"value":1000}]}}`)
+	}
+	return nil, errors.New("unexpected request " + r.Method + " " + r.URL.Path)
+}
+
+func TestWarmUp(t *testing.T) {
+	previousTransport, previousSnapshot := http.DefaultTransport, CountriesSnapshotFile()
+	defer func() {
+		http.DefaultTransport = previousTransport
+		SetCountriesSnapshotFile(previousSnapshot)
+		PurgeCache()
+	}()
+	SetCountriesSnapshotFile("")
+
+	for _, concurrency := range []int{1, 2} {
+		stub := newStubTransport(t)
+		http.DefaultTransport = stub
+		PurgeCache()
+
+		WarmUp(WarmUpConfig{PopulationTopN: 3, Concurrency: concurrency})
+
+		if stub.peak > concurrency {
+			t.Errorf("concurrency %d: %d upstream requests at once", concurrency, stub.peak)
+		}
+		for _, state := range stub.states {
+			if state != "running" {
+				t.Errorf("concurrency %d: state %q while prefetching, want running", concurrency, state)
+			}
+		}
+
+		// The country dataset and the population of Lesotho, Iceland and Antarctica
+		progress := WarmUpStatus()
+		if progress.State != "done" || progress.FinishedAt == nil || progress.FinishedAt.Before(progress.StartedAt) {
+			t.Errorf("concurrency %d: state %q finished at %v, want done after the start", concurrency, progress.State, progress.FinishedAt)
+		}
+		if progress.Total != 4 || progress.Completed != 4 || progress.Failed != 1 {
+			t.Errorf("concurrency %d: total %d, completed %d, failed %d, want 4, 4 and 1",
+				concurrency, progress.Total, progress.Completed, progress.Failed)
+		}
+		if len(progress.Errors) != 1 || progress.Errors[0] != "population of Antarctica: upstream unavailable" {
+			t.Errorf("concurrency %d: errors %q, want Antarctica with a generic reason", concurrency, progress.Errors)
+		}
+	}
+}
+
+func TestWarmUpDatasetFailure(t *testing.T) {
+	previousTransport, previousSnapshot := http.DefaultTransport, CountriesSnapshotFile()
+	defer func() {
+		http.DefaultTransport = previousTransport
+		SetCountriesSnapshotFile(previousSnapshot)
+		PurgeCache()
+	}()
+	SetCountriesSnapshotFile("")
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("dial tcp " + r.URL.Host + ": connection refused")
+	})
+	PurgeCache()
+
+	// Without the dataset there is nothing else to prefetch, and the upstream address is not reported
+	WarmUp(WarmUpConfig{PopulationTopN: 3, Concurrency: 2})
+	progress := WarmUpStatus()
+	if progress.State != "done" || progress.Total != 1 || progress.Completed != 1 || progress.Failed != 1 {
+		t.Errorf("progress = %+v, want the dataset as the only, failed resource", progress)
+	}
+	if len(progress.Errors) != 1 || progress.Errors[0] != "country dataset: upstream unavailable" {
+		t.Errorf("errors = %q, want the dataset with a generic reason", progress.Errors)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper answering requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
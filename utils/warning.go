@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"strings"
+)
+
+// Warning describes a part of a response that could not be retrieved, so that clients can tell
+// missing data apart from real data.
+type Warning struct {
+	Resource string `json:"resource"` // JSON field that is missing or incomplete
+	Message  string `json:"message"`
+}
+
+// NewWarning creates a warning for a resource that failed with err. The message only gives a fixed reason,
+// since errors may name upstream hosts and URLs; err itself is logged.
+func NewWarning(resource string, err error) Warning {
+	log.Printf("Warning for %s: %v", resource, err)
+	return Warning{Resource: resource, Message: "could not retrieve " + resource + ": " + failureReason(err)}
+}
+
+// failureReason describes why fetching from an upstream API failed, in words that are safe to show to clients.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrMaintenance):
+		return "upstream maintenance mode"
+	case strings.Contains(err.Error(), "not found"):
+		return "not found"
+	default:
+		return "upstream unavailable"
+	}
+}
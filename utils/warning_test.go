@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewWarning(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.New(`Post "http://129.241.150.113:3500/api/v0.1/countries/cities": connection refused`), "could not retrieve cities: upstream unavailable"},
+		{fmt.Errorf("failed to fetch cities: %w", ErrMaintenance), "could not retrieve cities: upstream maintenance mode"},
+		{errors.New("population API returned an error: country not found"), "could not retrieve cities: not found"},
+	}
+	for _, tt := range tests {
+		if got := NewWarning("cities", tt.err); got.Resource != "cities" || got.Message != tt.want {
+			t.Errorf("NewWarning(%v) = %+v, want message %q", tt.err, got, tt.want)
+		}
+	}
+}